@@ -2,44 +2,445 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws/endpoints"
 )
 
+// envPrefix is the prefix of the environment variables of the flags.
+const envPrefix = "EXTERNAL_DNS_AWS_MIGRATOR_"
+
+// Commands.
+const (
+	cmdAdopt    = "adopt"
+	cmdPlan     = "plan"
+	cmdApply    = "apply"
+	cmdSimulate = "simulate"
+	cmdAudit    = "audit"
+	cmdRollback = "rollback"
+	cmdTransfer = "transfer"
+	cmdCleanup  = "cleanup"
+	cmdReport   = "report"
+	cmdVersion  = "version"
+	cmdHelp     = "help"
+)
+
 // Defaults.
 const (
+	defCommand     = cmdAdopt
 	defTXTOwnerID  = "default"
 	defFilter      = `^.+$`
+	defConfig      = ""
 	defAWSRegion   = endpoints.EuWest1RegionID
+	defAWSProfile  = ""
+	defAWSRoleARN  = ""
 	defDryRun      = false
+	defPolicy      = "sync"
+	defVerbose     = false
+	defAnnotations = ""
+	defAnnFormat   = "kubectl"
+	defPreferCNAME = false
+	defToAlias     = false
+	defMergeTXT    = false
+	defPlanOut     = ""
+	defApply       = ""
+	defOnDrift     = "refuse"
+	defJournal     = ""
+	defResultsOut  = ""
+	defReportOut   = ""
+	defReportFmt   = "markdown"
+	defReportPlan  = ""
+	defReportJrnl  = ""
+	defMetricsAddr = ""
+	defPushgwURL   = ""
+	defPushgwJob   = "external-dns-aws-migrator"
+	defVerify      = false
+	defVerifyTO    = 5 * time.Minute
+	defVerifyIntv  = 5 * time.Second
+	defConcurrency = 1
+	defTimeout     = 0
+	defHostTimeout = 0
+	defWaveSize    = 0
+	defWatchDur    = 10 * time.Minute
+	defWatchIntv   = 30 * time.Second
+	defTXTPrefix   = ""
+	defOwnerRules  = ""
+	defTransferFrm = ""
+	defAuditFormat = "table"
+	defZoneFilter  = `^.+$`
+	defOrphanOwner = ""
+	defLogFormat   = "text"
+	defLogLevel    = "info"
+	defLogFile     = ""
 	defDebug       = false
-	defShowVersion = false
 )
 
 // Flags are the flags of the program.
 type Flags struct {
+	// Command is the subcommand being run.
+	Command     string
+	Config      string
 	AWSRegion   string
+	AWSProfile  string
+	AWSRoleARN  string
 	Filter      string
 	TXTOwnerID  string
 	DryRun      bool
+	Policy      string
+	Verbose     bool
+	Annotations string
+	AnnFormat   string
+	PreferCNAME bool
+	ToAlias     bool
+	MergeTXT    bool
+	PlanOut     string
+	Apply       string
+	OnDrift     string
+	Journal     string
+	ResultsOut  string
+	ReportOut   string
+	ReportFmt   string
+	ReportPlan  string
+	ReportJrnl  string
+	MetricsAddr string
+	PushgwURL   string
+	PushgwJob   string
+	Verify      bool
+	VerifyTO    time.Duration
+	VerifyIntv  time.Duration
+	Concurrency int
+	Timeout     time.Duration
+	HostTimeout time.Duration
+	WaveSize    int
+	WatchDur    time.Duration
+	WatchIntv   time.Duration
+	TXTPrefix   string
+	OwnerRules  string
+	TransferFrm string
+	AuditFormat string
+	ZoneFilter  string
+	OrphanOwner string
+	LogFormat   string
+	LogLevel    string
+	LogFile     string
 	Debug       bool
-	ShowVersion bool
+
+	// set are the flags set on the command line or the environment.
+	set map[string]bool
+}
+
+// command is a subcommand of the program with its scoped flags.
+type command struct {
+	name  string
+	args  string
+	help  string
+	aws   bool
+	flags func(fl *flag.FlagSet, f *Flags)
+}
+
+var commands = []command{
+	{
+		name: cmdAdopt,
+		args: "< hosts",
+		help: "Adopts the record sets of the hosts read from the stdin (one per line, optionally followed by the ingress) creating the external-dns ownership TXT records.",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			adoptFlags(fl, f)
+			fl.BoolVar(&f.DryRun, "dry-run", defDryRun, "run in dry-run mode")
+			fl.StringVar(&f.Journal, "journal", defJournal, "journal file with the result of each host, the hosts completed on the journal are skipped so interrupted runs can be resumed")
+			fl.BoolVar(&f.Verify, "verify", defVerify, "wait until the changes are INSYNC and the ownership txt records are served by the hosted zone nameservers")
+			fl.DurationVar(&f.VerifyTO, "verify-timeout", defVerifyTO, "maximum time waiting for the verification of each host")
+			fl.DurationVar(&f.VerifyIntv, "verify-interval", defVerifyIntv, "interval between the verification checks")
+			fl.IntVar(&f.WaveSize, "wave-size", defWaveSize, "adopt the hosts in waves of this size, watching the adopted record sets after each wave (0 disables the waves)")
+			fl.DurationVar(&f.WatchDur, "watch-duration", defWatchDur, "time watching the adopted record sets after each wave")
+			fl.DurationVar(&f.WatchIntv, "watch-interval", defWatchIntv, "interval between the polls of the watched record sets")
+		},
+	},
+	{
+		name: cmdPlan,
+		args: "-out <file> < hosts",
+		help: "Writes the Route53 changes of the adoption of the hosts read from the stdin to a reviewable plan file instead of applying them.",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			adoptFlags(fl, f)
+			fl.StringVar(&f.PlanOut, "out", defPlanOut, "file where the plan with the changes of the adoption will be written (required)")
+		},
+	},
+	{
+		name: cmdApply,
+		args: "-plan <file>",
+		help: "Applies the changes of a plan file, checking that the record sets didn't change since the plan.",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			fl.StringVar(&f.Apply, "plan", defApply, "plan file to apply (required)")
+			fl.StringVar(&f.OnDrift, "on-drift", defOnDrift, "what to do when the records changed since the plan (refuse the whole plan or skip the changed hosts)")
+			fl.BoolVar(&f.DryRun, "dry-run", defDryRun, "run in dry-run mode")
+		},
+	},
+	{
+		name: cmdSimulate,
+		args: "< endpoints",
+		help: "Simulates the external-dns plan after the adoption, reads the desired endpoints in JSON format (one per line) from the stdin.",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			filterFlags(fl, f)
+			registryFlags(fl, f)
+			fl.StringVar(&f.Policy, "policy", defPolicy, "external-dns policy used on the simulation (sync or upsert-only)")
+			fl.BoolVar(&f.Verbose, "verbose", defVerbose, "show also the unchanged hosts on the simulation")
+		},
+	},
+	{
+		name: cmdAudit,
+		help: "Audits the ownership of the records of the hosted zones (read only).",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			zoneFilterFlags(fl, f)
+			registryFlags(fl, f)
+			fl.StringVar(&f.AuditFormat, "format", defAuditFormat, "format of the audit report (table, json or csv)")
+		},
+	},
+	{
+		name: cmdRollback,
+		help: "Removes the ownership txt record sets created by the adoption of the filtered hosts and owner id.",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			filterFlags(fl, f)
+			registryFlags(fl, f)
+			fl.StringVar(&f.Journal, "journal", defJournal, "only roll back the hosts adopted on this journal file")
+			fl.BoolVar(&f.DryRun, "dry-run", defDryRun, "run in dry-run mode")
+		},
+	},
+	{
+		name: cmdTransfer,
+		args: "-from <owner id> -txt-owner-id <owner id>",
+		help: "Transfers the ownership of the filtered hosts from an owner id to the txt owner id.",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			filterFlags(fl, f)
+			registryFlags(fl, f)
+			fl.StringVar(&f.TransferFrm, "from", defTransferFrm, "owner id that currently owns the hosts (required)")
+			fl.BoolVar(&f.DryRun, "dry-run", defDryRun, "run in dry-run mode")
+		},
+	},
+	{
+		name: cmdCleanup,
+		help: "Deletes the ownership txt record sets without the A, AAAA or CNAME record that they own.",
+		aws:  true,
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			filterFlags(fl, f)
+			zoneFilterFlags(fl, f)
+			fl.StringVar(&f.TXTPrefix, "txt-prefix", defTXTPrefix, "the prefix of the txt registry record names, it can have the %{record_type} template")
+			fl.StringVar(&f.OrphanOwner, "orphan-owner-id", defOrphanOwner, "only clean up the orphans of this owner id (all owners if empty)")
+			fl.BoolVar(&f.DryRun, "dry-run", defDryRun, "run in dry-run mode")
+		},
+	},
+	{
+		name: cmdReport,
+		args: "-plan <file> | -journal <file>",
+		help: "Writes the report of a plan or journal file.",
+		flags: func(fl *flag.FlagSet, f *Flags) {
+			fl.StringVar(&f.ReportPlan, "plan", defReportPlan, "plan file to report")
+			fl.StringVar(&f.ReportJrnl, "journal", defReportJrnl, "journal file to report")
+			fl.StringVar(&f.TXTOwnerID, "txt-owner-id", defTXTOwnerID, "the txt owner id of the journal")
+			fl.StringVar(&f.ReportOut, "out", defReportOut, "file where the report will be written (- for the stdout)")
+			fl.StringVar(&f.ReportFmt, "format", defReportFmt, "format of the report (markdown or html)")
+		},
+	},
+	{
+		name: cmdVersion,
+		help: "Shows the version of the app.",
+	},
+}
+
+// adoptFlags registers the flags shared by the adoption and the plan.
+func adoptFlags(fl *flag.FlagSet, f *Flags) {
+	filterFlags(fl, f)
+	zoneFilterFlags(fl, f)
+	registryFlags(fl, f)
+	fl.StringVar(&f.Config, "config", defConfig, "JSON configuration file with the adoption jobs, the flags set override the values of all the jobs")
+	fl.StringVar(&f.OwnerRules, "owner-rules", defOwnerRules, "JSON file with the ordered rules that assign the owner ID of each adopted host instead of the txt owner id")
+	fl.StringVar(&f.Annotations, "annotations-out", defAnnotations, "file where the ingress patches with the annotations that preserve the adopted record sets attributes will be written")
+	fl.StringVar(&f.AnnFormat, "annotations-format", defAnnFormat, "format of the ingress annotation patches (kubectl or kustomize)")
+	fl.BoolVar(&f.PreferCNAME, "aws-prefer-cname", defPreferCNAME, "external-dns uses CNAMEs instead of alias records for the load balancers")
+	fl.BoolVar(&f.ToAlias, "convert-cname-to-alias", defToAlias, "convert the CNAMEs that point to load balancers to alias records when adopting")
+	fl.BoolVar(&f.MergeTXT, "merge-existing-txt", defMergeTXT, "add the ownership value to the existing non registry txt record sets (SPF, domain verification...) instead of failing")
+	fl.StringVar(&f.ResultsOut, "results-out", defResultsOut, "file where the result of each host will be written as a JSON object per line (- for the stdout)")
+	fl.StringVar(&f.ReportOut, "report-out", defReportOut, "file where the report of the adoption will be written (- for the stdout)")
+	fl.StringVar(&f.ReportFmt, "report-format", defReportFmt, "format of the report (markdown or html)")
+	fl.IntVar(&f.Concurrency, "concurrency", defConcurrency, "number of hosts adopted at the same time, the hosts of the same hosted zone are adopted one at a time")
+	fl.DurationVar(&f.Timeout, "timeout", defTimeout, "maximum time of the whole adoption, the hosts not adopted before are left for the next run (0 disables it)")
+	fl.DurationVar(&f.HostTimeout, "host-timeout", defHostTimeout, "maximum time of the adoption of each host, including the verification (0 disables it)")
+}
+
+func filterFlags(fl *flag.FlagSet, f *Flags) {
+	fl.StringVar(&f.Filter, "filter", defFilter, "regex to filter domains to act on")
+}
+
+func zoneFilterFlags(fl *flag.FlagSet, f *Flags) {
+	fl.StringVar(&f.ZoneFilter, "zone-filter", defZoneFilter, "regex to filter the hosted zones by name")
+}
+
+func registryFlags(fl *flag.FlagSet, f *Flags) {
+	fl.StringVar(&f.TXTOwnerID, "txt-owner-id", defTXTOwnerID, "the txt owner id that will be set on the txt registry")
+	fl.StringVar(&f.TXTPrefix, "txt-prefix", defTXTPrefix, "the prefix of the txt registry record names, it can have the %{record_type} template")
+}
+
+// logFlags registers the logging options shared by all the commands.
+func logFlags(fl *flag.FlagSet, f *Flags) {
+	fl.StringVar(&f.LogFormat, "log-format", defLogFormat, "format of the log lines (text or json)")
+	fl.StringVar(&f.LogLevel, "log-level", defLogLevel, "minimum level of the logged lines (debug, info, warn or error)")
+	fl.StringVar(&f.LogFile, "log-file", defLogFile, "file where the logs will be appended instead of the stderr")
+	fl.BoolVar(&f.Debug, "debug", defDebug, "run in debug mode (same as -log-level debug)")
 }
 
-// NewFlags returns the flags of the commandline.
+// awsFlags registers the AWS and metrics options shared by the commands that use Route53.
+func awsFlags(fl *flag.FlagSet, f *Flags) {
+	fl.StringVar(&f.AWSRegion, "aws-region", defAWSRegion, "AWS region to act on hosted zones")
+	fl.StringVar(&f.AWSProfile, "aws-profile", defAWSProfile, "profile of the AWS shared configuration files")
+	fl.StringVar(&f.AWSRoleARN, "aws-role-arn", defAWSRoleARN, "AWS role assumed to act on the hosted zones")
+	fl.StringVar(&f.MetricsAddr, "metrics-listen-address", defMetricsAddr, "address where the Prometheus metrics will be served on /metrics (disabled if empty)")
+	fl.StringVar(&f.PushgwURL, "pushgateway-url", defPushgwURL, "Prometheus Pushgateway URL where the metrics will be pushed at the end of the run (disabled if empty)")
+	fl.StringVar(&f.PushgwJob, "pushgateway-job", defPushgwJob, "job of the metrics pushed to the Pushgateway")
+}
+
+// movedFlags are the flags of the modes before the commands and their replacement.
+var movedFlags = map[string]string{
+	"simulate":        "simulate",
+	"rollback":        "rollback",
+	"audit":           "audit",
+	"audit-format":    "audit -format",
+	"cleanup-orphans": "cleanup",
+	"transfer-from":   "transfer -from",
+	"plan-out":        "plan -out",
+	"apply":           "apply -plan",
+	"report-plan":     "report -plan",
+	"report-journal":  "report -journal",
+}
+
+// NewFlags returns the flags of the commandline. The first argument is the command, the
+// adoption if it's missing.
 func NewFlags() *Flags {
-	flags := &Flags{}
-	fl := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	args := os.Args[1:]
+	name := defCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	// The flags of the modes before the commands, -version is still an alias of the version command.
+	if name == defCommand {
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				continue
+			}
+			old := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+			if old == cmdVersion {
+				fmt.Fprintf(os.Stderr, "-version is deprecated, use the version command\n")
+				name, args = cmdVersion, nil
+				break
+			}
+			if moved, ok := movedFlags[old]; ok {
+				fmt.Fprintf(os.Stderr, "-%s was moved, use %q (help shows the usage of the commands)\n", old, moved)
+				os.Exit(2)
+			}
+		}
+	}
 
-	fl.StringVar(&flags.AWSRegion, "aws-region", defAWSRegion, "AWS region to act on hosted zones")
-	fl.StringVar(&flags.Filter, "filter", defFilter, "regex to filter domains to act on")
-	fl.StringVar(&flags.TXTOwnerID, "txt-owner-id", defTXTOwnerID, "the txt owner id that will be set on the txt registry")
-	fl.BoolVar(&flags.DryRun, "dry-run", defDryRun, "run in dry-run mode")
-	fl.BoolVar(&flags.Debug, "debug", defDebug, "run in debug mode")
-	fl.BoolVar(&flags.ShowVersion, "version", defShowVersion, "show version of the app")
+	if name == cmdHelp {
+		if len(args) > 0 {
+			if cmd, ok := findCommand(args[0]); ok {
+				cmd.flagSet(&Flags{}).Usage()
+				os.Exit(0)
+			}
+		}
+		usage()
+		os.Exit(0)
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
 
-	fl.Parse(os.Args[1:])
+	flags := &Flags{Command: cmd.name}
+	fl := cmd.flagSet(flags)
+
+	// The environment variables override the defaults and the command line overrides them.
+	fl.VisitAll(func(f *flag.Flag) {
+		v, ok := os.LookupEnv(envVar(f.Name))
+		if !ok {
+			return
+		}
+		if err := fl.Set(f.Name, v); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid value %q for %s: %s\n", v, envVar(f.Name), err)
+			os.Exit(2)
+		}
+	})
+	fl.Parse(args)
+	if fl.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments %q\n\n", fl.Args())
+		fl.Usage()
+		os.Exit(2)
+	}
+
+	flags.set = map[string]bool{}
+	fl.Visit(func(f *flag.Flag) {
+		flags.set[f.Name] = true
+	})
 
 	return flags
 }
+
+// flagSet returns the flag set of the command with the shared options.
+func (c command) flagSet(f *Flags) *flag.FlagSet {
+	fl := flag.NewFlagSet(c.name, flag.ExitOnError)
+	if c.flags != nil {
+		c.flags(fl, f)
+	}
+	if c.name != cmdVersion {
+		logFlags(fl, f)
+	}
+	if c.aws {
+		awsFlags(fl, f)
+	}
+
+	fl.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n\n%s\n", strings.TrimSpace(fmt.Sprintf("Usage: %s %s [flags] %s", os.Args[0], c.name, c.args)), c.help)
+		if c.name != cmdVersion {
+			fmt.Fprintf(os.Stderr, "\nFlags (also set with %s<FLAG> environment variables):\n", envPrefix)
+			fl.PrintDefaults()
+		}
+	}
+	return fl
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// usage prints the commands of the program.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.help)
+	}
+	fmt.Fprintf(os.Stderr, "\nThe default command is %s. Use \"%s help <command>\" for the flags of a command.\n", defCommand, os.Args[0])
+}
+
+// IsSet returns true if the flag was set on the command line or the environment.
+func (f *Flags) IsSet(name string) bool {
+	return f.set[name]
+}
+
+// envVar returns the environment variable of a flag.
+func envVar(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/config"
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/metrics"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/annotate"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/journal"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/output"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/owner"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/plan"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/process"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/report"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/summary"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/verify"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/watch"
+)
+
+// defJobName is the name of the job of the flags when there is no configuration file.
+const defJobName = "default"
+
+// jobs returns the adoption jobs of the configuration file with the values set on the
+// flags (or the environment) overriding the ones of the file. Without configuration file
+// there is a single job with the flags.
+func (m *Main) jobs() ([]config.Job, error) {
+	flagsJob := config.Job{
+		Name:                defJobName,
+		Filter:              m.flags.Filter,
+		ZoneFilter:          m.flags.ZoneFilter,
+		TXTOwnerID:          m.flags.TXTOwnerID,
+		OwnerRules:          m.flags.OwnerRules,
+		TXTPrefix:           m.flags.TXTPrefix,
+		PreferCNAME:         m.flags.PreferCNAME,
+		ConvertCNAMEToAlias: m.flags.ToAlias,
+		MergeExistingTXT:    m.flags.MergeTXT,
+		AWS: config.AWS{
+			Region:  m.flags.AWSRegion,
+			Profile: m.flags.AWSProfile,
+			RoleARN: m.flags.AWSRoleARN,
+		},
+	}
+	if m.flags.Config == "" {
+		return []config.Job{flagsJob}, nil
+	}
+
+	cfg, err := config.Load(m.flags.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(dst *string, flag, v string) {
+		if m.flags.IsSet(flag) || *dst == "" {
+			*dst = v
+		}
+	}
+	boolean := func(dst *bool, flag string, v bool) {
+		if m.flags.IsSet(flag) || !*dst {
+			*dst = v
+		}
+	}
+	jobs := []config.Job{}
+	for _, job := range cfg.Jobs {
+		str(&job.Filter, "filter", flagsJob.Filter)
+		str(&job.ZoneFilter, "zone-filter", flagsJob.ZoneFilter)
+		str(&job.TXTOwnerID, "txt-owner-id", flagsJob.TXTOwnerID)
+		str(&job.OwnerRules, "owner-rules", flagsJob.OwnerRules)
+		str(&job.TXTPrefix, "txt-prefix", flagsJob.TXTPrefix)
+		boolean(&job.PreferCNAME, "aws-prefer-cname", flagsJob.PreferCNAME)
+		boolean(&job.ConvertCNAMEToAlias, "convert-cname-to-alias", flagsJob.ConvertCNAMEToAlias)
+		boolean(&job.MergeExistingTXT, "merge-existing-txt", flagsJob.MergeExistingTXT)
+		str(&job.AWS.Region, "aws-region", flagsJob.AWS.Region)
+		str(&job.AWS.Profile, "aws-profile", flagsJob.AWS.Profile)
+		str(&job.AWS.RoleARN, "aws-role-arn", flagsJob.AWS.RoleARN)
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// jobRun is an adoption job ready to run.
+type jobRun struct {
+	job   config.Job
+	hosts io.Reader
+	spsvc process.StreamAdopter
+	rpsvc report.Builder
+	plsvc plan.Planner
+}
+
+// adopt adopts the hosts of the jobs one job after the other, the summary, results and
+// report of all the jobs are combined. All the jobs are validated before adopting.
+func (m *Main) adopt() error {
+	jobs, err := m.jobs()
+	if err != nil {
+		return err
+	}
+	if m.flags.PlanOut != "" && len(jobs) > 1 {
+		return fmt.Errorf("the plan of multiple jobs is not supported, plan the jobs one by one")
+	}
+
+	// The outputs shared by the jobs.
+	var annsvc annotate.Annotator
+	if m.flags.Annotations != "" {
+		annsvc = annotate.NewAnnotator(m.logger)
+	}
+	var jrnl journal.Journal
+	if m.flags.Journal != "" {
+		jrnl, err = journal.NewFileJournal(m.flags.Journal, m.logger)
+		if err != nil {
+			return err
+		}
+		defer jrnl.Close()
+	}
+	colsvc := summary.NewCollector()
+	handlers := []process.ResultHandler{colsvc}
+	var outsvc output.Writer
+	if m.flags.ResultsOut != "" {
+		w := os.Stdout
+		if m.flags.ResultsOut != "-" {
+			f, err := os.Create(m.flags.ResultsOut)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		outsvc = output.NewJSONWriter(w)
+		handlers = append(handlers, outsvc)
+	}
+
+	runs := []*jobRun{}
+	var stdin []byte
+	for _, job := range jobs {
+		run, err := m.newJobRun(job, annsvc, jrnl, handlers)
+		if err != nil {
+			return fmt.Errorf("invalid job %q: %s", job.Name, err)
+		}
+
+		// The stdin is read once and shared by the jobs without hosts file.
+		if job.Hosts != "" {
+			b, err := ioutil.ReadFile(job.Hosts)
+			if err != nil {
+				return fmt.Errorf("invalid job %q: %s", job.Name, err)
+			}
+			run.hosts = bytes.NewReader(b)
+		} else {
+			if stdin == nil {
+				stdin, err = ioutil.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+			}
+			run.hosts = bytes.NewReader(stdin)
+		}
+		runs = append(runs, run)
+	}
+
+	ctx, cancel := m.interruptContext()
+	defer cancel()
+	if m.flags.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, m.flags.Timeout)
+		defer cancel()
+	}
+
+	// Start adopting, when interrupted the results and the report of the adopted hosts
+	// are written anyway.
+	var adoptErr error
+	for _, run := range runs {
+		if len(runs) > 1 {
+			m.logger.With("job", run.job.Name).Infof("adopting the hosts of the job")
+		}
+		adoptErr = run.spsvc.AdoptStream(ctx, run.hosts)
+		if adoptErr != nil {
+			break
+		}
+	}
+	sum := colsvc.Summary()
+	if log.Format(m.flags.LogFormat) == log.JSONFormat {
+		m.logSummary(sum)
+	} else if err := summary.WriteSummary(os.Stderr, sum); err != nil {
+		return err
+	}
+	if adoptErr != nil && ctx.Err() == nil {
+		return adoptErr
+	}
+	if outsvc != nil {
+		if err := outsvc.Err(); err != nil {
+			return fmt.Errorf("error writing the results: %s", err)
+		}
+	}
+
+	// The plan and the annotations of a partial run would be applied as if they
+	// were complete, so they are not written.
+	switch {
+	case adoptErr != nil && runs[0].plsvc != nil:
+		m.logger.Warnf("plan not written because the adoption was interrupted")
+	case runs[0].plsvc != nil:
+		if err := m.writePlan(runs[0].plsvc); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case adoptErr != nil && annsvc != nil:
+		m.logger.Warnf("annotations not written because the adoption was interrupted")
+	case annsvc != nil:
+		if err := m.writeAnnotations(annsvc); err != nil {
+			return err
+		}
+	}
+
+	if m.flags.ReportOut != "" {
+		reports := []*report.Report{}
+		for _, run := range runs {
+			reports = append(reports, run.rpsvc.Report())
+		}
+		r := reports[0]
+		if len(reports) > 1 {
+			r = report.Combine(reports...)
+		}
+		if err := m.writeReport(r); err != nil {
+			return err
+		}
+	}
+
+	if adoptErr != nil {
+		return fmt.Errorf("adoption interrupted: %s", adoptErr)
+	}
+
+	switch sum.Status() {
+	case summary.StatusPartialFailure:
+		return errPartialFailure
+	case summary.StatusFailure:
+		return errFailure
+	}
+	return nil
+}
+
+// newJobRun creates the services of a job.
+func (m *Main) newJobRun(job config.Job, annsvc annotate.Annotator, jrnl journal.Journal, handlers []process.ResultHandler) (*jobRun, error) {
+	if job.PreferCNAME && job.ConvertCNAMEToAlias {
+		return nil, fmt.Errorf("converting CNAMEs to alias records is not compatible with external-dns prefer CNAME")
+	}
+	fsvc, err := filter.NewEntryValidator(job.Filter, job.TXTOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	zoneFilter, err := regexp.Compile(job.ZoneFilter)
+	if err != nil {
+		return nil, err
+	}
+	r53cli, err := m.createAWSCli(job.AWS)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := m.logger
+	if job.Name != defJobName {
+		logger = logger.With("job", job.Name)
+	}
+	naming := registry.Naming{Prefix: job.TXTPrefix}
+	run := &jobRun{job: job}
+
+	adcfg := adopt.Config{
+		DryRun:              m.flags.DryRun,
+		Annotator:           annsvc,
+		PreferCNAME:         job.PreferCNAME,
+		Naming:              naming,
+		ConvertCNAMEToAlias: job.ConvertCNAMEToAlias,
+		MergeExistingTXT:    job.MergeExistingTXT,
+		ZoneFilter:          zoneFilter,
+	}
+	if job.OwnerRules != "" {
+		rules, err := owner.LoadRules(job.OwnerRules)
+		if err != nil {
+			return nil, err
+		}
+		adcfg.Owners, err = owner.NewAssigner(*rules)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m.flags.PlanOut != "" {
+		run.plsvc = plan.NewPlanner(job.TXTOwnerID)
+		adcfg.Recorder = run.plsvc
+	}
+	if m.flags.Verify {
+		adcfg.Verifier = verify.NewVerifier(verify.Config{
+			Timeout:  m.flags.VerifyTO,
+			Interval: m.flags.VerifyIntv,
+		}, r53cli, logger)
+	}
+	adsvc := adopt.NewRSAdopter(adcfg, r53cli, logger)
+	if jrnl != nil {
+		adsvc = journal.NewAdopter(adsvc, jrnl, logger)
+	}
+	adsvc = metrics.NewAdopter(adsvc, m.rec)
+
+	prcfg := process.Config{
+		WaveSize:    m.flags.WaveSize,
+		Concurrency: m.flags.Concurrency,
+		Timeout:     m.flags.HostTimeout,
+		Handlers:    handlers,
+	}
+	if m.flags.WaveSize > 0 {
+		prcfg.Watcher = watch.NewWatcher(watch.Config{
+			Duration: m.flags.WatchDur,
+			Interval: m.flags.WatchIntv,
+			Naming:   naming,
+		}, adopt.NewZoneIndex(r53cli), adopt.NewRecordSetGetter(r53cli), logger)
+	}
+	if m.flags.ReportOut != "" {
+		source := report.SourceRun
+		if m.flags.PlanOut != "" {
+			source = report.SourcePlan
+		}
+		run.rpsvc = report.NewBuilder(job.TXTOwnerID, source)
+		prcfg.Handlers = append(append([]process.ResultHandler{}, handlers...), run.rpsvc)
+		if prcfg.Watcher != nil {
+			prcfg.Watcher = report.NewWatcher(prcfg.Watcher, run.rpsvc)
+		}
+	}
+	run.spsvc = process.NewStreamAdopter(prcfg, adsvc, fsvc, logger)
+
+	return run, nil
+}
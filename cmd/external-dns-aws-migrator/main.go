@@ -1,23 +1,61 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
 
 	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"github.com/aws/aws-sdk-go-v2/aws/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
 
+	"github.com/slok/external-dns-aws-migrator/pkg/config"
 	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/metrics"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
 	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/annotate"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/audit"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/cleanup"
 	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/journal"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/plan"
 	"github.com/slok/external-dns-aws-migrator/pkg/service/process"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/report"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/rollback"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/simulate"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/summary"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/transfer"
 )
 
 const (
 	versionFMT = "external-dns-aws-migrator %s\n"
 )
 
+// Exit codes.
+const (
+	exitOK             = 0
+	exitError          = 1
+	exitPartialFailure = 2
+	exitFailure        = 3
+)
+
+var (
+	errPartialFailure = errors.New("some of the hosts were not adopted")
+	errFailure        = errors.New("none of the hosts were adopted")
+)
+
 var (
 	// Version is the app version.
 	Version = "dev"
@@ -27,20 +65,53 @@ var (
 type Main struct {
 	flags  *Flags
 	logger log.Logger
+	rec    metrics.Recorder
 }
 
 // Main is the main function that will be executed.
 func (m *Main) Main() error {
-
-	if m.flags.ShowVersion {
+	if m.flags.Command == cmdVersion {
 		m.printVersion()
 		return nil
 	}
 
-	r53cli := m.createAWSCli(defAWSRegion)
+	if err := m.setupLogger(); err != nil {
+		return err
+	}
+
+	// Invalid flags are reported before any AWS call.
+	if err := m.validate(); err != nil {
+		return err
+	}
 
-	if m.flags.Debug {
-		m.logger.Set("debug")
+	if m.flags.Command == cmdReport {
+		return m.report()
+	}
+
+	m.rec = metrics.Dummy
+	if m.flags.MetricsAddr != "" || m.flags.PushgwURL != "" {
+		reg := prometheus.NewRegistry()
+		m.rec = metrics.NewPrometheus(reg)
+		if m.flags.MetricsAddr != "" {
+			go m.serveMetrics(reg)
+		}
+		if m.flags.PushgwURL != "" {
+			defer m.pushMetrics(reg)
+		}
+	}
+
+	// The adoption and the plan create the clients of each job.
+	if m.flags.Command == cmdAdopt || m.flags.Command == cmdPlan {
+		return m.adopt()
+	}
+
+	r53cli, err := m.createAWSCli(config.AWS{
+		Region:  m.flags.AWSRegion,
+		Profile: m.flags.AWSProfile,
+		RoleARN: m.flags.AWSRoleARN,
+	})
+	if err != nil {
+		return err
 	}
 
 	// Create services.
@@ -48,30 +119,411 @@ func (m *Main) Main() error {
 	if err != nil {
 		return err
 	}
-	adsvc := adopt.NewRSAdopter(m.flags.DryRun, r53cli, m.logger)
-	spsvc := process.NewStreamAdopter(adsvc, fsvc, m.logger)
 
-	// Start adopting.
-	err = spsvc.AdoptStream(os.Stdin)
-	if err != nil {
+	ctx := context.Background()
+	switch m.flags.Command {
+	case cmdSimulate:
+		return m.simulate(ctx, r53cli, fsvc)
+	case cmdRollback:
+		return m.rollback(ctx, r53cli, fsvc)
+	case cmdAudit:
+		return m.audit(ctx, r53cli)
+	case cmdCleanup:
+		clsvc, err := cleanup.NewCleaner(cleanup.Config{
+			DryRun:     m.flags.DryRun,
+			OwnerID:    m.flags.OrphanOwner,
+			ZoneFilter: m.flags.ZoneFilter,
+			Naming:     m.naming(),
+		}, r53cli, m.logger)
+		if err != nil {
+			return err
+		}
+		return clsvc.Clean(ctx, fsvc)
+	case cmdApply:
+		return m.apply(ctx, r53cli)
+	case cmdTransfer:
+		trsvc := transfer.NewTransferer(transfer.Config{
+			DryRun: m.flags.DryRun,
+			Naming: m.naming(),
+		}, r53cli, m.logger)
+		return trsvc.Transfer(ctx, fsvc, m.flags.TransferFrm, m.flags.TXTOwnerID)
+	}
+
+	return fmt.Errorf("unknown command %q", m.flags.Command)
+}
+
+// validate checks the flags of the command.
+func (m *Main) validate() error {
+	for name, v := range map[string]string{"filter": m.flags.Filter, "zone-filter": m.flags.ZoneFilter} {
+		if _, err := regexp.Compile(v); err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+	}
+
+	switch m.flags.Command {
+	case cmdAdopt, cmdPlan:
+		if m.flags.Command == cmdPlan && m.flags.PlanOut == "" {
+			return fmt.Errorf("the plan file is required (-out)")
+		}
+		if m.flags.PreferCNAME && m.flags.ToAlias {
+			return fmt.Errorf("converting CNAMEs to alias records is not compatible with external-dns prefer CNAME")
+		}
+		if m.flags.Concurrency < 1 {
+			return fmt.Errorf("invalid concurrency %d", m.flags.Concurrency)
+		}
+		switch annotate.Format(m.flags.AnnFormat) {
+		case annotate.KubectlFormat, annotate.KustomizeFormat:
+		default:
+			return fmt.Errorf("invalid annotations format %q", m.flags.AnnFormat)
+		}
+		return m.validateReportFormat()
+	case cmdApply:
+		if m.flags.Apply == "" {
+			return fmt.Errorf("the plan file is required (-plan)")
+		}
+		switch plan.DriftPolicy(m.flags.OnDrift) {
+		case plan.RefuseDriftPolicy, plan.SkipDriftPolicy:
+		default:
+			return fmt.Errorf("invalid drift policy %q", m.flags.OnDrift)
+		}
+	case cmdSimulate:
+		switch simulate.Policy(m.flags.Policy) {
+		case simulate.SyncPolicy, simulate.UpsertOnlyPolicy:
+		default:
+			return fmt.Errorf("invalid policy %q", m.flags.Policy)
+		}
+	case cmdAudit:
+		switch audit.Format(m.flags.AuditFormat) {
+		case audit.TableFormat, audit.JSONFormat, audit.CSVFormat:
+		default:
+			return fmt.Errorf("invalid audit format %q", m.flags.AuditFormat)
+		}
+	case cmdTransfer:
+		if m.flags.TransferFrm == "" {
+			return fmt.Errorf("the owner id to transfer from is required (-from)")
+		}
+		// The default owner id is never the intended target of a transfer.
+		if !m.flags.IsSet("txt-owner-id") {
+			return fmt.Errorf("the owner id to transfer to is required (-txt-owner-id)")
+		}
+		if m.flags.TransferFrm == m.flags.TXTOwnerID {
+			return fmt.Errorf("the owner id to transfer from and to are the same (%q)", m.flags.TXTOwnerID)
+		}
+	case cmdReport:
+		if (m.flags.ReportPlan == "") == (m.flags.ReportJrnl == "") {
+			return fmt.Errorf("one of the plan (-plan) or journal (-journal) files is required")
+		}
+		return m.validateReportFormat()
+	}
+	return nil
+}
+
+// validateReportFormat checks the format of the report.
+func (m *Main) validateReportFormat() error {
+	switch report.Format(m.flags.ReportFmt) {
+	case report.MarkdownFormat, report.HTMLFormat:
+	default:
+		return fmt.Errorf("invalid report format %q", m.flags.ReportFmt)
+	}
+	return nil
+}
+
+// setupLogger sets the format, level and output of the logger.
+func (m *Main) setupLogger() error {
+	if err := m.logger.SetFormat(log.Format(m.flags.LogFormat)); err != nil {
 		return err
 	}
 
+	level := m.flags.LogLevel
+	if m.flags.Debug {
+		level = "debug"
+	}
+	switch level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	if err := m.logger.Set(log.Level(level)); err != nil {
+		return err
+	}
+
+	if m.flags.LogFile != "" {
+		// The file is not closed so the logs are written until the program exits.
+		f, err := os.OpenFile(m.flags.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		m.logger.SetOutput(f)
+	}
 	return nil
 }
 
-func (m *Main) createAWSCli(awsRegion string) route53iface.Route53API {
-	// and credentials values from the environment variables, shared
-	// credentials, and shared configuration files
-	cfg, err := external.LoadDefaultAWSConfig()
+// logSummary logs the summary with a line per zone and the counts as fields.
+func (m *Main) logSummary(sum *summary.Summary) {
+	line := func(zone string, counts map[model.Outcome]int) log.Logger {
+		logger := m.logger.With("zone", zone)
+		for _, o := range model.Outcomes {
+			logger = logger.With(string(o), counts[o])
+		}
+		return logger
+	}
+	for _, z := range sum.Zones {
+		line(z.Zone, z.Counts).Infof("zone summary")
+	}
+	line("", sum.Counts).With("status", sum.Status()).Infof("adoption summary")
+}
+
+// interruptContext returns a context that is canceled on the first SIGINT or SIGTERM,
+// the following ones kill the program.
+func (m *Main) interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			m.logger.Warningf("%s received, waiting for the in-flight adoptions (again to exit now)", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigs)
+	}()
+
+	return ctx, cancel
+}
+
+// serveMetrics serves the metrics on the metrics listen address.
+func (m *Main) serveMetrics(reg *prometheus.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	m.logger.Infof("serving metrics on %s", m.flags.MetricsAddr)
+	if err := http.ListenAndServe(m.flags.MetricsAddr, mux); err != nil {
+		m.logger.Errorf("error serving metrics: %s", err)
+	}
+}
+
+// pushMetrics pushes the metrics to the Pushgateway.
+func (m *Main) pushMetrics(reg *prometheus.Registry) {
+	if err := push.New(m.flags.PushgwURL, m.flags.PushgwJob).Gatherer(reg).Push(); err != nil {
+		m.logger.Errorf("error pushing metrics: %s", err)
+		return
+	}
+	m.logger.Infof("metrics pushed to %s", m.flags.PushgwURL)
+}
+
+// writePlan writes the plan file with the changes of the adoption.
+func (m *Main) writePlan(plsvc plan.Planner) error {
+	f, err := os.Create(m.flags.PlanOut)
 	if err != nil {
-		panic("unable to load SDK config, " + err.Error())
+		return err
+	}
+	defer f.Close()
+
+	return plan.WritePlan(f, plsvc.Plan())
+}
+
+// apply applies the changes of a plan file.
+func (m *Main) apply(ctx context.Context, r53cli route53iface.Route53API) error {
+	f, err := os.Open(m.flags.Apply)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
 
-	// Set the AWS Region that the service clients should use
-	cfg.Region = awsRegion
+	p, err := plan.ReadPlan(f)
+	if err != nil {
+		return err
+	}
 
-	return route53.New(cfg)
+	apsvc, err := plan.NewApplier(plan.ApplyConfig{
+		DryRun:  m.flags.DryRun,
+		OnDrift: plan.DriftPolicy(m.flags.OnDrift),
+	}, r53cli, m.logger)
+	if err != nil {
+		return err
+	}
+	return apsvc.Apply(ctx, p)
+}
+
+// report writes the report of a plan or journal file.
+func (m *Main) report() error {
+	path := m.flags.ReportJrnl
+	if m.flags.ReportPlan != "" {
+		path = m.flags.ReportPlan
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var rpsvc report.Builder
+	if m.flags.ReportPlan != "" {
+		p, err := plan.ReadPlan(f)
+		if err != nil {
+			return err
+		}
+		rpsvc = report.NewBuilder(p.OwnerID, report.SourcePlan)
+		for _, res := range report.PlanResults(p) {
+			rpsvc.Handle(res)
+		}
+	} else {
+		records, err := journal.ReadRecords(f, m.logger)
+		if err != nil {
+			return err
+		}
+		rpsvc = report.NewBuilder(m.flags.TXTOwnerID, report.SourceJournal)
+		for _, r := range records {
+			res := r.Result
+			rpsvc.Handle(&res)
+		}
+	}
+
+	return m.writeReport(rpsvc.Report())
+}
+
+// writeReport writes the report of the adoption.
+func (m *Main) writeReport(r *report.Report) error {
+	if m.flags.ReportOut == "" || m.flags.ReportOut == "-" {
+		return report.WriteReport(os.Stdout, report.Format(m.flags.ReportFmt), r)
+	}
+
+	f, err := os.Create(m.flags.ReportOut)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return report.WriteReport(f, report.Format(m.flags.ReportFmt), r)
+}
+
+// writeAnnotations writes the ingress annotation patches of the adopted hosts.
+func (m *Main) writeAnnotations(annsvc annotate.Annotator) error {
+	f, err := os.Create(m.flags.Annotations)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return annotate.WritePatches(f, annotate.Format(m.flags.AnnFormat), annsvc.Patches())
+}
+
+// simulate prints the plan that external-dns will apply after the adoption.
+func (m *Main) simulate(ctx context.Context, r53cli route53iface.Route53API, fsvc filter.EntryValidator) error {
+	eps, err := process.ReadEndpoints(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	// Only the hosts that would be adopted.
+	valid := []*model.Endpoint{}
+	for _, ep := range eps {
+		if _, err := fsvc.Validate(ctx, ep.Host); err != nil {
+			m.logger.Debugf("ignoring domain %s", ep.Host)
+			continue
+		}
+		valid = append(valid, ep)
+	}
+
+	simsvc, err := simulate.NewSimulator(simulate.Config{
+		OwnerID: m.flags.TXTOwnerID,
+		Policy:  simulate.Policy(m.flags.Policy),
+		Naming:  m.naming(),
+	}, adopt.NewZoneIndex(r53cli), adopt.NewRecordSetGetter(r53cli), m.logger)
+	if err != nil {
+		return err
+	}
+	simPlan, err := simsvc.Simulate(ctx, valid)
+	if err != nil {
+		return err
+	}
+
+	return simulate.WriteDiff(os.Stdout, simPlan, m.flags.Verbose)
+}
+
+// rollback removes the ownership txt record sets created by a previous adoption.
+func (m *Main) rollback(ctx context.Context, r53cli route53iface.Route53API, fsvc filter.EntryValidator) error {
+	rbsvc := rollback.NewRollbacker(rollback.Config{
+		DryRun: m.flags.DryRun,
+		Naming: m.naming(),
+	}, r53cli, m.logger)
+
+	if m.flags.Journal == "" {
+		entries, err := rbsvc.Find(ctx, fsvc)
+		if err != nil {
+			return err
+		}
+		return rbsvc.Rollback(ctx, entries)
+	}
+
+	// Only the hosts adopted on the journal.
+	f, err := os.Open(m.flags.Journal)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	records, err := journal.ReadRecords(f, m.logger)
+	if err != nil {
+		return err
+	}
+
+	// Exactly the txt records written on the journal, regardless of the current owner.
+	results := []*model.Result{}
+	for _, res := range journal.AdoptedResults(records) {
+		if _, err := fsvc.Validate(ctx, res.Host); err != nil {
+			m.logger.Debugf("ignoring domain %s", res.Host)
+			continue
+		}
+		results = append(results, res)
+	}
+	return rbsvc.RollbackResults(ctx, results)
+}
+
+// audit writes the ownership audit report of the hosted zones.
+func (m *Main) audit(ctx context.Context, r53cli route53iface.Route53API) error {
+	ausvc, err := audit.NewAuditor(audit.Config{
+		OwnerID:    m.flags.TXTOwnerID,
+		ZoneFilter: m.flags.ZoneFilter,
+		Naming:     m.naming(),
+	}, adopt.NewZoneIndex(r53cli), adopt.NewRecordSetGetter(r53cli), m.logger)
+	if err != nil {
+		return err
+	}
+
+	report, err := ausvc.Audit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return audit.WriteReport(os.Stdout, audit.Format(m.flags.AuditFormat), report)
+}
+
+// naming returns the naming of the external-dns txt registry records.
+func (m *Main) naming() registry.Naming {
+	return registry.Naming{Prefix: m.flags.TXTPrefix}
+}
+
+// createAWSCli creates the Route53 client with the region and credentials, the metrics of
+// the API calls are recorded.
+func (m *Main) createAWSCli(awsCfg config.AWS) (route53iface.Route53API, error) {
+	// Load the credentials values from the environment variables, shared
+	// credentials, and shared configuration files.
+	opts := []external.Config{}
+	if awsCfg.Profile != "" {
+		opts = append(opts, external.WithSharedConfigProfile(awsCfg.Profile))
+	}
+	cfg, err := external.LoadDefaultAWSConfig(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %s", err)
+	}
+
+	// Set the AWS Region that the service clients should use.
+	cfg.Region = awsCfg.Region
+	if awsCfg.RoleARN != "" {
+		cfg.Credentials = stscreds.NewAssumeRoleProvider(sts.New(cfg), awsCfg.RoleARN)
+	}
+
+	return metrics.NewRoute53(route53.New(cfg), m.rec), nil
 }
 
 // printVersion prints the version of the app.
@@ -87,9 +539,24 @@ func main() {
 	}
 	err := m.Main()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error executing program: %s\n", err)
-		os.Exit(1)
+		if log.Format(flags.LogFormat) == log.JSONFormat {
+			m.logger.Errorf("error executing program: %s", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error executing program: %s\n", err)
+		}
+		os.Exit(exitCode(err))
 	}
 
-	os.Exit(0)
+	os.Exit(exitOK)
+}
+
+// exitCode returns the exit code of the error.
+func exitCode(err error) int {
+	switch err {
+	case errPartialFailure:
+		return exitPartialFailure
+	case errFailure:
+		return exitFailure
+	}
+	return exitError
 }
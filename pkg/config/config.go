@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Config is the configuration file of the migrator with the migration jobs.
+type Config struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// Job is a migration job, the empty fields take the value of the flags.
+type Job struct {
+	// Name identifies the job on the logs.
+	Name string `json:"name"`
+	// Hosts is the file with the hosts of the job, the stdin if empty.
+	Hosts string `json:"hosts,omitempty"`
+	// Filter is the regex that filters the hosts of the job.
+	Filter string `json:"filter,omitempty"`
+	// ZoneFilter is the regex that selects the hosted zones of the job by name.
+	ZoneFilter string `json:"zoneFilter,omitempty"`
+	// TXTOwnerID is the external-dns owner ID of the job.
+	TXTOwnerID string `json:"txtOwnerID,omitempty"`
+	// OwnerRules is the file with the rules that assign the owner ID of each host instead
+	// of the TXTOwnerID.
+	OwnerRules string `json:"ownerRules,omitempty"`
+	// TXTPrefix is the external-dns TXT registry prefix of the job.
+	TXTPrefix string `json:"txtPrefix,omitempty"`
+	// PreferCNAME is the external-dns prefer CNAME option of the job.
+	PreferCNAME bool `json:"preferCNAME,omitempty"`
+	// ConvertCNAMEToAlias converts the CNAMEs that point to load balancers to alias records.
+	ConvertCNAMEToAlias bool `json:"convertCNAMEToAlias,omitempty"`
+	// MergeExistingTXT adds the ownership value to the existing non registry TXT record sets.
+	MergeExistingTXT bool `json:"mergeExistingTXT,omitempty"`
+	// AWS are the AWS settings of the job.
+	AWS AWS `json:"aws,omitempty"`
+}
+
+// AWS are the AWS region and credentials settings.
+type AWS struct {
+	// Region is the AWS region.
+	Region string `json:"region,omitempty"`
+	// Profile is the profile of the AWS shared configuration files.
+	Profile string `json:"profile,omitempty"`
+	// RoleARN is the role assumed with the credentials.
+	RoleARN string `json:"roleARN,omitempty"`
+}
+
+// Read reads a JSON configuration.
+func Read(r io.Reader) (*Config, error) {
+	cfg := &Config{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Load loads the JSON configuration file.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+func (c *Config) validate() error {
+	if len(c.Jobs) == 0 {
+		return fmt.Errorf("invalid configuration: no jobs")
+	}
+
+	names := map[string]bool{}
+	for i, j := range c.Jobs {
+		if j.Name == "" {
+			return fmt.Errorf("invalid configuration: job %d without name", i)
+		}
+		if names[j.Name] {
+			return fmt.Errorf("invalid configuration: duplicated job %q", j.Name)
+		}
+		names[j.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/config"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expCfg *config.Config
+		expErr bool
+	}{
+		{
+			name: "A configuration with multiple jobs should be read.",
+			cfg: `{"jobs": [
+  {"name": "dc", "filter": "\\.dc\\.comics$", "txtOwnerID": "batman", "aws": {"region": "us-east-1", "profile": "dc"}},
+  {"name": "marvel", "hosts": "marvel.txt", "zoneFilter": "^marvel\\.", "txtOwnerID": "deadpool", "txtPrefix": "%{record_type}-", "mergeExistingTXT": true}
+]}`,
+			expCfg: &config.Config{Jobs: []config.Job{
+				{Name: "dc", Filter: `\.dc\.comics$`, TXTOwnerID: "batman", AWS: config.AWS{Region: "us-east-1", Profile: "dc"}},
+				{Name: "marvel", Hosts: "marvel.txt", ZoneFilter: `^marvel\.`, TXTOwnerID: "deadpool", TXTPrefix: "%{record_type}-", MergeExistingTXT: true},
+			}},
+		},
+		{
+			name:   "A configuration without jobs should fail.",
+			cfg:    `{"jobs": []}`,
+			expErr: true,
+		},
+		{
+			name:   "A configuration with a job without name should fail.",
+			cfg:    `{"jobs": [{"txtOwnerID": "batman"}]}`,
+			expErr: true,
+		},
+		{
+			name:   "A configuration with duplicated jobs should fail.",
+			cfg:    `{"jobs": [{"name": "dc"}, {"name": "dc"}]}`,
+			expErr: true,
+		},
+		{
+			name:   "A configuration with unknown fields should fail.",
+			cfg:    `{"jobs": [{"name": "dc", "ownerID": "batman"}]}`,
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cfg, err := config.Read(bytes.NewBufferString(test.cfg))
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expCfg, cfg)
+			}
+		})
+	}
+}
@@ -1,5 +1,9 @@
 package log
 
+import (
+	"io"
+)
+
 // Dummy is a dummy logger
 var Dummy = DummyLogger{}
 
@@ -28,3 +32,5 @@ func (l DummyLogger) Panicf(string, ...interface{})                  {}
 func (l DummyLogger) With(key string, value interface{}) Logger      { return l }
 func (l DummyLogger) WithField(key string, value interface{}) Logger { return l }
 func (l DummyLogger) Set(level Level) error                          { return nil }
+func (l DummyLogger) SetFormat(format Format) error                  { return nil }
+func (l DummyLogger) SetOutput(w io.Writer)                          {}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"runtime"
 	"strings"
 
@@ -11,6 +12,17 @@ import (
 // Level refers to the level of logging
 type Level string
 
+// Format is the format of the log lines.
+type Format string
+
+// Formats.
+const (
+	// TextFormat logs the lines as key=value pairs.
+	TextFormat Format = "text"
+	// JSONFormat logs the lines as JSON objects, the fields are keys of the object.
+	JSONFormat Format = "json"
+)
+
 // Logger is an interface that needs to be implemented in order to log.
 type Logger interface {
 	Debug(...interface{})
@@ -41,6 +53,8 @@ type Logger interface {
 	With(key string, value interface{}) Logger
 	WithField(key string, value interface{}) Logger
 	Set(level Level) error
+	SetFormat(format Format) error
+	SetOutput(w io.Writer)
 }
 
 type logger struct {
@@ -137,6 +151,22 @@ func (l *logger) Set(level Level) error {
 	return nil
 }
 
+func (l *logger) SetFormat(format Format) error {
+	switch format {
+	case TextFormat:
+		l.entry.Logger.Formatter = &logrus.TextFormatter{}
+	case JSONFormat:
+		l.entry.Logger.Formatter = &logrus.JSONFormatter{}
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+	return nil
+}
+
+func (l *logger) SetOutput(w io.Writer) {
+	l.entry.Logger.Out = w
+}
+
 func (l logger) sourced() *logrus.Entry {
 	_, file, line, ok := runtime.Caller(3)
 	if !ok {
@@ -250,6 +280,16 @@ func Set(level Level) error {
 	return baseLogger.Set(level)
 }
 
+// SetFormat will set the logger format
+func SetFormat(format Format) error {
+	return baseLogger.SetFormat(format)
+}
+
+// SetOutput will set the writer where the logger writes
+func SetOutput(w io.Writer) {
+	baseLogger.SetOutput(w)
+}
+
 // Panic logs panic message
 func Panic(args ...interface{}) {
 	baseLogger.Panic(args...)
@@ -0,0 +1,37 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+)
+
+func TestLoggerJSONFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	var b bytes.Buffer
+	logger := log.Base()
+	logger.SetOutput(&b)
+	defer logger.SetOutput(os.Stderr)
+	if !assert.NoError(logger.SetFormat(log.JSONFormat)) {
+		return
+	}
+	defer logger.SetFormat(log.TextFormat)
+
+	logger.With("hz", "Z123").With("host", "batman.gotham.dc.comics").Infof("entry\nadopted")
+	logger.Debugf("not logged")
+
+	line := map[string]interface{}{}
+	if assert.NoError(json.Unmarshal(b.Bytes(), &line)) {
+		assert.Equal("Z123", line["hz"])
+		assert.Equal("batman.gotham.dc.comics", line["host"])
+		assert.Equal("entry\nadopted", line["msg"])
+		assert.Equal("info", line["level"])
+	}
+	assert.Error(logger.SetFormat("yaml"))
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+)
+
+type adopter struct {
+	adSvc adopt.RSAdopter
+	rec   Recorder
+}
+
+// NewAdopter returns an adopter that records the outcome of every adoption.
+func NewAdopter(adSvc adopt.RSAdopter, rec Recorder) adopt.RSAdopter {
+	return &adopter{
+		adSvc: adSvc,
+		rec:   rec,
+	}
+}
+
+func (a *adopter) Adopt(ctx context.Context, entry *model.Entry) (*model.Result, error) {
+	res, err := a.adSvc.Adopt(ctx, entry)
+	if res != nil {
+		a.rec.IncHost(res.Outcome)
+	}
+	return res, err
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+// Dummy is a dummy recorder
+var Dummy = DummyRecorder{}
+
+// DummyRecorder is an empty recorder mainly used for tests
+type DummyRecorder struct{}
+
+func (r DummyRecorder) IncHost(model.Outcome)         {}
+func (r DummyRecorder) IncRoute53Call(string, string) {}
+func (r DummyRecorder) AddRoute53Retries(string, int) {}
+func (r DummyRecorder) IncRoute53Throttle(string)     {}
+func (r DummyRecorder) ObserveChangeBatchSize(int)    {}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+const (
+	namespace = "external_dns_aws_migrator"
+	subsystem = "route53"
+)
+
+// Recorder knows how to record the metrics of the migrator.
+type Recorder interface {
+	// IncHost counts a processed host by outcome.
+	IncHost(outcome model.Outcome)
+	// IncRoute53Call counts a Route53 API call by operation and error code (OK on success).
+	IncRoute53Call(operation, code string)
+	// AddRoute53Retries counts the retries of a Route53 API call.
+	AddRoute53Retries(operation string, retries int)
+	// IncRoute53Throttle counts a throttled Route53 API call attempt.
+	IncRoute53Throttle(operation string)
+	// ObserveChangeBatchSize observes the number of changes of a Route53 change batch.
+	ObserveChangeBatchSize(size int)
+}
+
+type recorder struct {
+	hosts      *prometheus.CounterVec
+	calls      *prometheus.CounterVec
+	retries    *prometheus.CounterVec
+	throttles  *prometheus.CounterVec
+	batchSizes prometheus.Histogram
+}
+
+// NewPrometheus returns a recorder that registers the metrics on the Prometheus registerer.
+func NewPrometheus(reg prometheus.Registerer) Recorder {
+	r := &recorder{
+		hosts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "hosts_total",
+			Help:      "Hosts processed by outcome.",
+		}, []string{"outcome"}),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Route53 API calls by operation and error code.",
+		}, []string{"operation", "code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "retries_total",
+			Help:      "Route53 API call retries by operation.",
+		}, []string{"operation"}),
+		throttles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "throttles_total",
+			Help:      "Throttled Route53 API call attempts by operation.",
+		}, []string{"operation"}),
+		batchSizes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "change_batch_size",
+			Help:      "Changes per Route53 change batch.",
+			Buckets:   []float64{1, 2, 5, 10, 25, 50, 100},
+		}),
+	}
+
+	reg.MustRegister(r.hosts, r.calls, r.retries, r.throttles, r.batchSizes)
+	return r
+}
+
+func (r *recorder) IncHost(outcome model.Outcome) {
+	r.hosts.WithLabelValues(string(outcome)).Inc()
+}
+
+func (r *recorder) IncRoute53Call(operation, code string) {
+	r.calls.WithLabelValues(operation, code).Inc()
+}
+
+func (r *recorder) AddRoute53Retries(operation string, retries int) {
+	r.retries.WithLabelValues(operation).Add(float64(retries))
+}
+
+func (r *recorder) IncRoute53Throttle(operation string) {
+	r.throttles.WithLabelValues(operation).Inc()
+}
+
+func (r *recorder) ObserveChangeBatchSize(size int) {
+	r.batchSizes.Observe(float64(size))
+}
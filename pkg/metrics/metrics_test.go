@@ -0,0 +1,97 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/metrics"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	madopt "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+// gather returns the metrics of the registry in the Prometheus text format.
+func gather(reg *prometheus.Registry) string {
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return w.Body.String()
+}
+
+func TestRoute53(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expOut []string
+	}{
+		{
+			name: "A successful change batch should be counted with its size.",
+			expOut: []string{
+				`external_dns_aws_migrator_route53_requests_total{code="OK",operation="ChangeResourceRecordSets"} 1`,
+				`external_dns_aws_migrator_route53_change_batch_size_count 1`,
+				`external_dns_aws_migrator_route53_change_batch_size_sum 2`,
+			},
+		},
+		{
+			name: "A throttled change batch should be counted with its error code.",
+			err:  awserr.New("Throttling", "Rate exceeded", nil),
+			expOut: []string{
+				`external_dns_aws_migrator_route53_requests_total{code="Throttling",operation="ChangeResourceRecordSets"} 1`,
+				`external_dns_aws_migrator_route53_throttles_total{operation="ChangeResourceRecordSets"} 1`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			req := &aws.Request{
+				Data:        &route53.ChangeResourceRecordSetsOutput{},
+				HTTPRequest: &http.Request{},
+			}
+			err := test.err
+			req.Handlers.Send.PushBack(func(r *aws.Request) {
+				r.Error = err
+			})
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Return(route53.ChangeResourceRecordSetsRequest{Request: req})
+
+			reg := prometheus.NewRegistry()
+			r53 := metrics.NewRoute53(mr53, metrics.NewPrometheus(reg))
+			r53.ChangeResourceRecordSetsRequest(&route53.ChangeResourceRecordSetsInput{
+				ChangeBatch: &route53.ChangeBatch{Changes: []route53.Change{{}, {}}},
+			}).Send()
+
+			out := gather(reg)
+			for _, line := range test.expOut {
+				assert.Contains(out, line)
+			}
+		})
+	}
+}
+
+func TestAdopter(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks.
+	ma := &madopt.RSAdopter{}
+	ma.On("Adopt", mock.Anything, mock.Anything).Return(&model.Result{Outcome: model.OutcomeOwnerConflict}, nil)
+
+	reg := prometheus.NewRegistry()
+	ad := metrics.NewAdopter(ma, metrics.NewPrometheus(reg))
+	ad.Adopt(context.Background(), &model.Entry{})
+	ad.Adopt(context.Background(), &model.Entry{})
+
+	assert.Contains(gather(reg), `external_dns_aws_migrator_hosts_total{outcome="owner-conflict"} 2`)
+}
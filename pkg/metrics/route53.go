@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+)
+
+// route53API measures the Route53 API calls used by the migrator, the rest of the
+// calls go directly to the wrapped client.
+type route53API struct {
+	route53iface.Route53API
+	rec Recorder
+}
+
+// NewRoute53 returns a Route53 client that records the metrics of the API calls.
+func NewRoute53(r53Svc route53iface.Route53API, rec Recorder) route53iface.Route53API {
+	return &route53API{
+		Route53API: r53Svc,
+		rec:        rec,
+	}
+}
+
+func (r *route53API) ListHostedZonesRequest(input *route53.ListHostedZonesInput) route53.ListHostedZonesRequest {
+	req := r.Route53API.ListHostedZonesRequest(input)
+	r.measure(req.Request, "ListHostedZones")
+	return req
+}
+
+func (r *route53API) ListResourceRecordSetsRequest(input *route53.ListResourceRecordSetsInput) route53.ListResourceRecordSetsRequest {
+	req := r.Route53API.ListResourceRecordSetsRequest(input)
+	r.measure(req.Request, "ListResourceRecordSets")
+	return req
+}
+
+func (r *route53API) ChangeResourceRecordSetsRequest(input *route53.ChangeResourceRecordSetsInput) route53.ChangeResourceRecordSetsRequest {
+	req := r.Route53API.ChangeResourceRecordSetsRequest(input)
+	r.measure(req.Request, "ChangeResourceRecordSets")
+	if input != nil && input.ChangeBatch != nil {
+		size := len(input.ChangeBatch.Changes)
+		req.Handlers.Complete.PushBack(func(ar *aws.Request) {
+			if ar.Error == nil {
+				r.rec.ObserveChangeBatchSize(size)
+			}
+		})
+	}
+	return req
+}
+
+func (r *route53API) GetChangeRequest(input *route53.GetChangeInput) route53.GetChangeRequest {
+	req := r.Route53API.GetChangeRequest(input)
+	r.measure(req.Request, "GetChange")
+	return req
+}
+
+func (r *route53API) GetHostedZoneRequest(input *route53.GetHostedZoneInput) route53.GetHostedZoneRequest {
+	req := r.Route53API.GetHostedZoneRequest(input)
+	r.measure(req.Request, "GetHostedZone")
+	return req
+}
+
+// measure adds the handlers that record the metrics of the request once it's sent.
+func (r *route53API) measure(req *aws.Request, operation string) {
+	if req == nil {
+		return
+	}
+
+	// The retry handlers run after each failed attempt.
+	req.Handlers.Retry.PushFront(func(ar *aws.Request) {
+		if ar.IsErrorThrottle() {
+			r.rec.IncRoute53Throttle(operation)
+		}
+	})
+	req.Handlers.Complete.PushBack(func(ar *aws.Request) {
+		r.rec.IncRoute53Call(operation, errorCode(ar.Error))
+		if ar.RetryCount > 0 {
+			r.rec.AddRoute53Retries(operation, ar.RetryCount)
+		}
+	})
+}
+
+// errorCode returns the AWS error code of the error.
+func errorCode(err error) string {
+	if err == nil {
+		return "OK"
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	return "Unknown"
+}
@@ -9,3 +9,6 @@ package mocks // import "github.com/slok/external-dns-aws-migrator/pkg/mocks"
 // Service mocks.
 //go:generate mockery -output ./service/adopt -outpkg adopt -dir ../service/adopt -name RSAdopter
 //go:generate mockery -output ./service/filter -outpkg adopt -dir ../service/filter -name EntryValidator
+//go:generate mockery -output ./service/annotate -outpkg annotate -dir ../service/annotate -name Annotator
+//go:generate mockery -output ./service/verify -outpkg verify -dir ../service/verify -name Verifier
+//go:generate mockery -output ./service/watch -outpkg watch -dir ../service/watch -name Watcher
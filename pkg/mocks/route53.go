@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// These are the fixtures shared by the tests that mock the Route53 API.
+
+// RecordSet returns a record set with the values.
+func RecordSet(name string, t route53.RRType, ttl int64, values ...string) route53.ResourceRecordSet {
+	rs := route53.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: t,
+		TTL:  aws.Int64(ttl),
+	}
+	for _, v := range values {
+		rs.ResourceRecords = append(rs.ResourceRecords, route53.ResourceRecord{Value: aws.String(v)})
+	}
+	return rs
+}
+
+// TXTRecordSet returns a TXT record set with the values.
+func TXTRecordSet(name string, values ...string) route53.ResourceRecordSet {
+	return RecordSet(name, route53.RRTypeTxt, 300, values...)
+}
+
+// ListHostedZonesRequest returns a request whose response has the hosted zones.
+func ListHostedZonesRequest(hzs ...route53.HostedZone) route53.ListHostedZonesRequest {
+	return route53.ListHostedZonesRequest{
+		Request: &aws.Request{
+			HTTPRequest: &http.Request{},
+			Data:        &route53.ListHostedZonesOutput{HostedZones: hzs},
+		},
+	}
+}
+
+// ListResourceRecordSetsRequest returns a request whose response has the record sets.
+func ListResourceRecordSetsRequest(rrs ...route53.ResourceRecordSet) route53.ListResourceRecordSetsRequest {
+	return route53.ListResourceRecordSetsRequest{
+		Request: &aws.Request{
+			HTTPRequest: &http.Request{},
+			Data:        &route53.ListResourceRecordSetsOutput{ResourceRecordSets: rrs},
+		},
+	}
+}
+
+// ChangeResourceRecordSetsRequest returns a request with an empty response.
+func ChangeResourceRecordSetsRequest() route53.ChangeResourceRecordSetsRequest {
+	return route53.ChangeResourceRecordSetsRequest{
+		Request: &aws.Request{
+			HTTPRequest: &http.Request{},
+			Data:        &route53.ChangeResourceRecordSetsOutput{},
+		},
+	}
+}
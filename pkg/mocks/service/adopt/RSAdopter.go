@@ -1,6 +1,7 @@
 // Code generated by mockery v1.0.0
 package adopt
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import model "github.com/slok/external-dns-aws-migrator/pkg/model"
 
@@ -9,16 +10,25 @@ type RSAdopter struct {
 	mock.Mock
 }
 
-// Adopt provides a mock function with given fields: _a0
-func (_m *RSAdopter) Adopt(_a0 *model.Entry) error {
-	ret := _m.Called(_a0)
+// Adopt provides a mock function with given fields: _a0, _a1
+func (_m *RSAdopter) Adopt(_a0 context.Context, _a1 *model.Entry) (*model.Result, error) {
+	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*model.Entry) error); ok {
-		r0 = rf(_a0)
+	var r0 *model.Result
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Entry) *model.Result); ok {
+		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Result)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Entry) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
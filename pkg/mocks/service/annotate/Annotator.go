@@ -0,0 +1,33 @@
+// Code generated by mockery v1.0.0
+package annotate
+
+import annotate "github.com/slok/external-dns-aws-migrator/pkg/service/annotate"
+import mock "github.com/stretchr/testify/mock"
+import model "github.com/slok/external-dns-aws-migrator/pkg/model"
+import route53 "github.com/aws/aws-sdk-go-v2/service/route53"
+
+// Annotator is an autogenerated mock type for the Annotator type
+type Annotator struct {
+	mock.Mock
+}
+
+// Annotate provides a mock function with given fields: entry, rrs
+func (_m *Annotator) Annotate(entry *model.Entry, rrs []route53.ResourceRecordSet) {
+	_m.Called(entry, rrs)
+}
+
+// Patches provides a mock function with given fields:
+func (_m *Annotator) Patches() []*annotate.Patch {
+	ret := _m.Called()
+
+	var r0 []*annotate.Patch
+	if rf, ok := ret.Get(0).(func() []*annotate.Patch); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*annotate.Patch)
+		}
+	}
+
+	return r0
+}
@@ -1,6 +1,7 @@
 // Code generated by mockery v1.0.0
 package adopt
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import model "github.com/slok/external-dns-aws-migrator/pkg/model"
 
@@ -9,13 +10,13 @@ type EntryValidator struct {
 	mock.Mock
 }
 
-// Validate provides a mock function with given fields: host
-func (_m *EntryValidator) Validate(host string) (*model.Entry, error) {
-	ret := _m.Called(host)
+// Validate provides a mock function with given fields: ctx, host
+func (_m *EntryValidator) Validate(ctx context.Context, host string) (*model.Entry, error) {
+	ret := _m.Called(ctx, host)
 
 	var r0 *model.Entry
-	if rf, ok := ret.Get(0).(func(string) *model.Entry); ok {
-		r0 = rf(host)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Entry); ok {
+		r0 = rf(ctx, host)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Entry)
@@ -23,8 +24,8 @@ func (_m *EntryValidator) Validate(host string) (*model.Entry, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(host)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, host)
 	} else {
 		r1 = ret.Error(1)
 	}
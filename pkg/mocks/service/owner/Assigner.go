@@ -0,0 +1,34 @@
+// Code generated by mockery v1.0.0
+package owner
+
+import mock "github.com/stretchr/testify/mock"
+import model "github.com/slok/external-dns-aws-migrator/pkg/model"
+import owner "github.com/slok/external-dns-aws-migrator/pkg/service/owner"
+
+// Assigner is an autogenerated mock type for the Assigner type
+type Assigner struct {
+	mock.Mock
+}
+
+// Assign provides a mock function with given fields: host, zone, records
+func (_m *Assigner) Assign(host string, zone string, records []model.Record) (*owner.Assignment, error) {
+	ret := _m.Called(host, zone, records)
+
+	var r0 *owner.Assignment
+	if rf, ok := ret.Get(0).(func(string, string, []model.Record) *owner.Assignment); ok {
+		r0 = rf(host, zone, records)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*owner.Assignment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, []model.Record) error); ok {
+		r1 = rf(host, zone, records)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,25 @@
+// Code generated by mockery v1.0.0
+package verify
+
+import context "context"
+import mock "github.com/stretchr/testify/mock"
+import verify "github.com/slok/external-dns-aws-migrator/pkg/service/verify"
+
+// Verifier is an autogenerated mock type for the Verifier type
+type Verifier struct {
+	mock.Mock
+}
+
+// Verify provides a mock function with given fields: ctx, hzID, changeID, txts
+func (_m *Verifier) Verify(ctx context.Context, hzID string, changeID string, txts []verify.TXT) error {
+	ret := _m.Called(ctx, hzID, changeID, txts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []verify.TXT) error); ok {
+		r0 = rf(ctx, hzID, changeID, txts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
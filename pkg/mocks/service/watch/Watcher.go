@@ -0,0 +1,35 @@
+// Code generated by mockery v1.0.0
+package watch
+
+import context "context"
+import mock "github.com/stretchr/testify/mock"
+import model "github.com/slok/external-dns-aws-migrator/pkg/model"
+import watch "github.com/slok/external-dns-aws-migrator/pkg/service/watch"
+
+// Watcher is an autogenerated mock type for the Watcher type
+type Watcher struct {
+	mock.Mock
+}
+
+// Watch provides a mock function with given fields: ctx, results
+func (_m *Watcher) Watch(ctx context.Context, results []*model.Result) (*watch.Report, error) {
+	ret := _m.Called(ctx, results)
+
+	var r0 *watch.Report
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.Result) *watch.Report); ok {
+		r0 = rf(ctx, results)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*watch.Report)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.Result) error); ok {
+		r1 = rf(ctx, results)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,16 @@
+package model
+
+// The external-dns annotations that are set on the sources (e.g. ingresses).
+const (
+	TargetAnnotation                = "external-dns.alpha.kubernetes.io/target"
+	TTLAnnotation                   = "external-dns.alpha.kubernetes.io/ttl"
+	AliasAnnotation                 = "external-dns.alpha.kubernetes.io/alias"
+	SetIdentifierAnnotation         = "external-dns.alpha.kubernetes.io/set-identifier"
+	AWSWeightAnnotation             = "external-dns.alpha.kubernetes.io/aws-weight"
+	AWSRegionAnnotation             = "external-dns.alpha.kubernetes.io/aws-region"
+	AWSFailoverAnnotation           = "external-dns.alpha.kubernetes.io/aws-failover"
+	AWSGeoContinentCodeAnnotation   = "external-dns.alpha.kubernetes.io/aws-geolocation-continent-code"
+	AWSGeoCountryCodeAnnotation     = "external-dns.alpha.kubernetes.io/aws-geolocation-country-code"
+	AWSGeoSubdivisionCodeAnnotation = "external-dns.alpha.kubernetes.io/aws-geolocation-subdivision-code"
+	AWSHealthCheckIDAnnotation      = "external-dns.alpha.kubernetes.io/aws-health-check-id"
+)
@@ -0,0 +1,10 @@
+package model
+
+// Endpoint is the desired state of a host, the same that external-dns
+// would get from the source (e.g. an ingress).
+type Endpoint struct {
+	Host        string            `json:"host"`
+	Targets     []string          `json:"targets"`
+	TTL         int64             `json:"ttl,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
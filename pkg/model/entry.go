@@ -4,4 +4,6 @@ package model
 type Entry struct {
 	Host string
 	TXT  string
+	// Ingress is the ingress (namespace/name) that has the host, optional.
+	Ingress string
 }
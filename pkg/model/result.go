@@ -0,0 +1,110 @@
+package model
+
+// Outcome is the outcome of the adoption of an entry.
+type Outcome string
+
+// Outcomes.
+const (
+	// OutcomeAdopted is when the ownership TXT records have been created.
+	OutcomeAdopted Outcome = "adopted"
+	// OutcomeAlreadyOwned is when the ownership TXT records already exist with the same owner.
+	OutcomeAlreadyOwned Outcome = "already-owned"
+	// OutcomeUnverified is when the ownership TXT records have been created but
+	// they were not served by the nameservers of the hosted zone in time.
+	OutcomeUnverified Outcome = "unverified"
+	// OutcomeOwnerConflict is when the host is owned by another external-dns owner.
+	OutcomeOwnerConflict Outcome = "owner-conflict"
+	// OutcomeExistingTXT is when a TXT that is not from the external-dns registry
+	// (SPF, domain verification...) already uses the ownership record name.
+	OutcomeExistingTXT Outcome = "existing-txt"
+	// OutcomeMissingRecord is when the host doesn't have an A, AAAA or CNAME record.
+	OutcomeMissingRecord Outcome = "missing-record"
+	// OutcomeUnsupported is when the records of the host can't be managed by external-dns.
+	OutcomeUnsupported Outcome = "unsupported"
+	// OutcomeNoZone is when there isn't a hosted zone for the host.
+	OutcomeNoZone Outcome = "no-zone"
+	// OutcomeError is when the adoption failed unexpectedly.
+	OutcomeError Outcome = "error"
+	// OutcomeSkipped is when the host doesn't match the filter.
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// Outcomes are all the outcomes in order.
+var Outcomes = []Outcome{
+	OutcomeAdopted,
+	OutcomeAlreadyOwned,
+	OutcomeUnverified,
+	OutcomeOwnerConflict,
+	OutcomeExistingTXT,
+	OutcomeMissingRecord,
+	OutcomeUnsupported,
+	OutcomeNoZone,
+	OutcomeError,
+	OutcomeSkipped,
+}
+
+// Success returns true if the host is owned after the adoption.
+func (o Outcome) Success() bool {
+	return o == OutcomeAdopted || o == OutcomeAlreadyOwned
+}
+
+// Failure returns true if the host should have been adopted and it's not owned.
+func (o Outcome) Failure() bool {
+	return !o.Success() && o != OutcomeSkipped
+}
+
+// Action is the action taken on the ownership TXT records of a host.
+type Action string
+
+// Actions.
+const (
+	// ActionNone is when the ownership TXT records are not changed.
+	ActionNone Action = "none"
+	// ActionCreate is when the ownership TXT records are created.
+	ActionCreate Action = "create"
+	// ActionMerge is when the ownership value is added to an existing TXT record set.
+	ActionMerge Action = "merge"
+)
+
+// Record is a record set of a host.
+type Record struct {
+	Type          string   `json:"type"`
+	SetIdentifier string   `json:"setIdentifier,omitempty"`
+	TTL           int64    `json:"ttl,omitempty"`
+	Values        []string `json:"values,omitempty"`
+	// Alias is the DNS name of the alias target.
+	Alias string `json:"alias,omitempty"`
+}
+
+// TXT is an ownership TXT record.
+type TXT struct {
+	Name          string `json:"name"`
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	Value         string `json:"value"`
+	// Merged is when the value was added to an existing TXT record set.
+	Merged bool `json:"merged,omitempty"`
+}
+
+// Result is the result of the adoption of an entry.
+type Result struct {
+	Host    string  `json:"host"`
+	Zone    string  `json:"zone,omitempty"`
+	ZoneID  string  `json:"zoneID,omitempty"`
+	Outcome Outcome `json:"outcome"`
+	Action  Action  `json:"action,omitempty"`
+	// Records are the record sets found for the host.
+	Records []Record `json:"records,omitempty"`
+	// TXTs are the ownership TXT records written (or to be written on dry-run).
+	TXTs []TXT `json:"txts,omitempty"`
+	// Owner is the owner ID of the existing ownership TXT record.
+	Owner string `json:"owner,omitempty"`
+	// Rule is the owner rule that assigned the owner ID of the host.
+	Rule string `json:"rule,omitempty"`
+	// Message explains the outcome.
+	Message string `json:"message,omitempty"`
+	// ChangeID is the ID of the Route53 change of the adoption.
+	ChangeID string `json:"changeID,omitempty"`
+	DryRun   bool   `json:"dryRun,omitempty"`
+	// Error is the unexpected error of the adoption.
+	Error string `json:"error,omitempty"`
+}
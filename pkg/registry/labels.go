@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	heritage       = "external-dns"
+	heritageKey    = "heritage"
+	labelKeyPrefix = "external-dns/"
+
+	// OwnerKey is the label key of the registry owner ID.
+	OwnerKey = "owner"
+	// ResourceKey is the label key of the source resource of the record.
+	ResourceKey = "resource"
+)
+
+// ErrNotRegistryTXT is returned when a TXT value is not an external-dns registry value.
+var ErrNotRegistryTXT = errors.New("txt value is not an external-dns registry value")
+
+// Labels are the external-dns registry labels stored in the ownership TXT records.
+type Labels map[string]string
+
+// NewLabels returns the labels of a record owned by the owner ID.
+func NewLabels(ownerID string) Labels {
+	return Labels{OwnerKey: ownerID}
+}
+
+// ParseTXT parses an external-dns ownership TXT value, the value can be
+// quoted or not. If the value is not an external-dns registry value it will
+// return ErrNotRegistryTXT.
+func ParseTXT(value string) (Labels, error) {
+	value = strings.Trim(value, `"`)
+	tokens := strings.Split(value, ",")
+
+	// The first one always is the heritage.
+	if tokens[0] != fmt.Sprintf("%s=%s", heritageKey, heritage) {
+		return nil, ErrNotRegistryTXT
+	}
+
+	labels := Labels{}
+	for _, token := range tokens[1:] {
+		kv := strings.SplitN(token, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], labelKeyPrefix) {
+			return nil, fmt.Errorf("invalid label %q: %s", token, ErrNotRegistryTXT)
+		}
+		labels[strings.TrimPrefix(kv[0], labelKeyPrefix)] = kv[1]
+	}
+
+	return labels, nil
+}
+
+// Owner returns the owner ID of the labels.
+func (l Labels) Owner() string {
+	return l[OwnerKey]
+}
+
+// String returns the TXT value (unquoted) of the labels in the same
+// format external-dns writes them.
+func (l Labels) String() string {
+	keys := []string{}
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tokens := []string{fmt.Sprintf("%s=%s", heritageKey, heritage)}
+	for _, k := range keys {
+		tokens = append(tokens, fmt.Sprintf("%s%s=%s", labelKeyPrefix, k, l[k]))
+	}
+
+	return strings.Join(tokens, ",")
+}
@@ -0,0 +1,55 @@
+package registry_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+)
+
+func TestParseTXT(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expLabels registry.Labels
+		expErr    bool
+	}{
+		{
+			name:      "A quoted registry TXT should be parsed.",
+			value:     `"heritage=external-dns,external-dns/owner=batman"`,
+			expLabels: registry.Labels{"owner": "batman"},
+		},
+		{
+			name:      "A registry TXT with multiple labels should be parsed.",
+			value:     `heritage=external-dns,external-dns/owner=batman,external-dns/resource=ingress/gotham/cave`,
+			expLabels: registry.Labels{"owner": "batman", "resource": "ingress/gotham/cave"},
+		},
+		{
+			name:   "A non registry TXT should fail.",
+			value:  `"v=spf1 include:_spf.google.com ~all"`,
+			expErr: true,
+		},
+		{
+			name:   "A registry TXT with invalid labels should fail.",
+			value:  `"heritage=external-dns,owner=batman"`,
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			labels, err := registry.ParseTXT(test.value)
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expLabels, labels)
+				// Serializing again should return the same value.
+				assert.Equal(strings.Trim(test.value, `"`), labels.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"strings"
+)
+
+const recordTypeTemplate = "%{record_type}"
+
+// recordTypes are the record types that external-dns manages.
+var recordTypes = []string{"a", "aaaa", "cname"}
+
+// Naming is the naming of the external-dns TXT registry records.
+type Naming struct {
+	// Prefix is the prefix of the TXT record names, it can have the %{record_type}
+	// template that will be replaced by the lowercase type of the owned record.
+	Prefix string
+}
+
+// TXTName returns the name of the ownership TXT record of a host record.
+func (n Naming) TXTName(host, recordType string) string {
+	prefix := strings.Replace(n.Prefix, recordTypeTemplate, strings.ToLower(recordType), -1)
+	return prefix + host
+}
+
+// TypePrefixed returns true if the TXT names have the type of the owned record.
+func (n Naming) TypePrefixed() bool {
+	return strings.Contains(n.Prefix, recordTypeTemplate)
+}
+
+// Host returns the host of the record owned by the TXT record name, it will return false
+// if the name is not valid for the naming.
+func (n Naming) Host(txtName string) (string, bool) {
+	host, _, ok := n.Owned(txtName)
+	return host, ok
+}
+
+// Owned returns the host and the lowercase record type of the record owned by the TXT record
+// name, the type will be empty if the naming is not type prefixed. It will return false
+// if the name is not valid for the naming.
+func (n Naming) Owned(txtName string) (host, recordType string, ok bool) {
+	if !n.TypePrefixed() {
+		if !strings.HasPrefix(txtName, n.Prefix) || txtName == n.Prefix {
+			return "", "", false
+		}
+		return strings.TrimPrefix(txtName, n.Prefix), "", true
+	}
+
+	for _, t := range recordTypes {
+		prefix := strings.Replace(n.Prefix, recordTypeTemplate, t, -1)
+		if strings.HasPrefix(txtName, prefix) && txtName != prefix {
+			return strings.TrimPrefix(txtName, prefix), t, true
+		}
+	}
+
+	return "", "", false
+}
+
+// Variants returns the namings that an external-dns may have used for the TXT registry
+// records of the same hosts: this one, the legacy one (no prefix) and the type prefixed
+// ones that external-dns >= 0.12 writes along with the legacy ones.
+func (n Naming) Variants() []Naming {
+	variants := []Naming{n}
+	candidates := []Naming{{}, {Prefix: recordTypeTemplate + "-"}}
+	if n.Prefix != "" && !n.TypePrefixed() {
+		candidates = append(candidates, Naming{Prefix: recordTypeTemplate + "-" + n.Prefix})
+	}
+	for _, c := range candidates {
+		if c != n {
+			variants = append(variants, c)
+		}
+	}
+	return variants
+}
+
+// Hosts returns the hosts of the records that the TXT record name may own with any of
+// the naming variants.
+func (n Naming) Hosts(txtName string) []string {
+	hosts := []string{}
+	seen := map[string]bool{}
+	for _, v := range n.Variants() {
+		host, ok := v.Host(txtName)
+		if ok && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
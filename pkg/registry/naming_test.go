@@ -0,0 +1,90 @@
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+)
+
+func TestNaming(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		host       string
+		recordType string
+		expTXTName string
+	}{
+		{
+			name:       "Legacy naming should use the same name of the record.",
+			host:       "batman.gotham.dc.comics",
+			recordType: "CNAME",
+			expTXTName: "batman.gotham.dc.comics",
+		},
+		{
+			name:       "Prefixed naming should prefix the name of the record.",
+			prefix:     "registry-",
+			host:       "batman.gotham.dc.comics",
+			recordType: "CNAME",
+			expTXTName: "registry-batman.gotham.dc.comics",
+		},
+		{
+			name:       "Type prefixed naming should prefix the name of the record with the record type.",
+			prefix:     "%{record_type}-",
+			host:       "batman.gotham.dc.comics",
+			recordType: "AAAA",
+			expTXTName: "aaaa-batman.gotham.dc.comics",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			n := registry.Naming{Prefix: test.prefix}
+			gotTXTName := n.TXTName(test.host, test.recordType)
+			assert.Equal(test.expTXTName, gotTXTName)
+
+			// And back to the host.
+			gotHost, ok := n.Host(gotTXTName)
+			assert.True(ok)
+			assert.Equal(test.host, gotHost)
+		})
+	}
+}
+
+func TestNamingHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		txtName  string
+		expHosts []string
+	}{
+		{
+			name:     "A type prefixed name with the legacy naming should have the legacy and the type prefixed hosts.",
+			txtName:  "cname-batman.gotham.dc.comics",
+			expHosts: []string{"cname-batman.gotham.dc.comics", "batman.gotham.dc.comics"},
+		},
+		{
+			name:     "A legacy name should have only the legacy host.",
+			txtName:  "batman.gotham.dc.comics",
+			expHosts: []string{"batman.gotham.dc.comics"},
+		},
+		{
+			name:     "A type prefixed name with a prefixed naming should have the prefixed hosts.",
+			prefix:   "registry-",
+			txtName:  "a-registry-batman.gotham.dc.comics",
+			expHosts: []string{"a-registry-batman.gotham.dc.comics", "registry-batman.gotham.dc.comics", "batman.gotham.dc.comics"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			n := registry.Naming{Prefix: test.prefix}
+			assert.Equal(test.expHosts, n.Hosts(test.txtName))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// ZoneOwnership is the ownership of the records of a hosted zone based on its TXT registry records.
+type ZoneOwnership struct {
+	naming  Naming
+	owners  map[string]string
+	records map[string]bool
+	txts    []route53.ResourceRecordSet
+}
+
+// NewZoneOwnership returns the ownership of the hosted zone record sets.
+func NewZoneOwnership(rrs []route53.ResourceRecordSet, naming Naming) *ZoneOwnership {
+	z := &ZoneOwnership{
+		naming:  naming,
+		owners:  map[string]string{},
+		records: map[string]bool{},
+	}
+
+	for _, rs := range rrs {
+		name := normalizeName(aws.StringValue(rs.Name))
+		id := aws.StringValue(rs.SetIdentifier)
+		switch rs.Type {
+		case route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname:
+			// Index by the record type and without it so we can check siblings on both namings.
+			z.records[recordKey(name, strings.ToLower(string(rs.Type)), id)] = true
+			z.records[recordKey(name, "", id)] = true
+		case route53.RRTypeTxt:
+			if _, ok := naming.Host(name); !ok {
+				continue
+			}
+			if owner, ok := TXTOwner(rs); ok {
+				z.owners[recordKey(name, "", id)] = owner
+				z.txts = append(z.txts, rs)
+			}
+		}
+	}
+
+	return z
+}
+
+// Owner returns the owner of the record set, false if is not owned by any external-dns.
+func (z *ZoneOwnership) Owner(rs route53.ResourceRecordSet) (string, bool) {
+	name := normalizeName(aws.StringValue(rs.Name))
+	txtName := z.naming.TXTName(name, string(rs.Type))
+	owner, ok := z.owners[recordKey(txtName, "", aws.StringValue(rs.SetIdentifier))]
+	return owner, ok
+}
+
+// Orphans returns the ownership TXT record sets that don't have the record that they own.
+// A TXT record set is not an orphan if it owns a record with any of the naming variants,
+// so the type prefixed records (`cname-foo`) are not mistaken by orphans with the legacy
+// naming and the other way around.
+func (z *ZoneOwnership) Orphans() []route53.ResourceRecordSet {
+	orphans := []route53.ResourceRecordSet{}
+	for _, rs := range z.txts {
+		if !z.ownsRecord(rs) {
+			orphans = append(orphans, rs)
+		}
+	}
+
+	return orphans
+}
+
+func (z *ZoneOwnership) ownsRecord(rs route53.ResourceRecordSet) bool {
+	name := normalizeName(aws.StringValue(rs.Name))
+	for _, n := range z.naming.Variants() {
+		host, t, ok := n.Owned(name)
+		if ok && z.records[recordKey(host, t, aws.StringValue(rs.SetIdentifier))] {
+			return true
+		}
+	}
+	return false
+}
+
+// TXTOwner returns the owner of an ownership TXT record set, false if it's not a registry record.
+func TXTOwner(rs route53.ResourceRecordSet) (string, bool) {
+	for _, r := range rs.ResourceRecords {
+		labels, err := ParseTXT(aws.StringValue(r.Value))
+		if err == nil {
+			return labels.Owner(), true
+		}
+	}
+	return "", false
+}
+
+func recordKey(name, recordType, setID string) string {
+	return name + "/" + recordType + "/" + setID
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -1,7 +1,9 @@
 package adopt
 
 import (
+	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -10,88 +12,301 @@ import (
 
 	"github.com/slok/external-dns-aws-migrator/pkg/log"
 	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/annotate"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/owner"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/verify"
 )
 
 // RSAdopter is the Route53 AWS record set adopter, it will get a txt Entry and it will addopt the entry in the required route53 hosted zone.
+// The result has the outcome of the adoption, the error is only returned when the adoption failed unexpectedly.
+// The changes are not sent once the context is done.
 type RSAdopter interface {
-	Adopt(*model.Entry) error
+	Adopt(context.Context, *model.Entry) (*model.Result, error)
+}
+
+// Config is the configuration of the adopter.
+type Config struct {
+	// DryRun will not make any change on Route53.
+	DryRun bool
+	// Annotator if set, will get the record sets of the adopted entries.
+	Annotator annotate.Annotator
+	// PreferCNAME is the external-dns prefer CNAME option used to check the shape of the records.
+	PreferCNAME bool
+	// Naming is the naming of the external-dns TXT registry records.
+	Naming registry.Naming
+	// ConvertCNAMEToAlias will convert the CNAMEs that point to AWS load balancers to alias
+	// records in the same change batch of the TXT creation.
+	ConvertCNAMEToAlias bool
+	// MergeExistingTXT will add the ownership value to the TXT record sets that already exist
+	// and are not from the registry (SPF, domain verification...) instead of failing.
+	MergeExistingTXT bool
+	// Recorder if set, will get the changes of the adoptions instead of applying them on Route53.
+	Recorder ChangeRecorder
+	// Verifier if set, will wait until the ownership TXT records are served by the hosted
+	// zone nameservers before reporting the host as adopted.
+	Verifier verify.Verifier
+	// ZoneFilter if set, only adopts the hosts of the hosted zones whose name matches it,
+	// the rest are skipped.
+	ZoneFilter *regexp.Regexp
+	// Owners if set, assigns the owner ID of each host instead of using the one of the entry,
+	// the hosts without owner ID are skipped.
+	Owners owner.Assigner
 }
 
 type adopter struct {
-	r53Svc route53iface.Route53API
-	dryRun bool
-	logger log.Logger
+	cfg       Config
+	r53Svc    route53iface.Route53API
+	zones     ZoneIndex
+	rrsGetter RecordSetGetter
+	locks     *zoneLocks
+	logger    log.Logger
 }
 
 // NewRSAdopter is the implementation of the RSAdopter
-func NewRSAdopter(dryRun bool, r53Svc route53iface.Route53API, logger log.Logger) RSAdopter {
+func NewRSAdopter(cfg Config, r53Svc route53iface.Route53API, logger log.Logger) RSAdopter {
 	return &adopter{
-		r53Svc: r53Svc,
-		dryRun: dryRun,
-		logger: logger,
+		cfg:       cfg,
+		r53Svc:    r53Svc,
+		zones:     NewZoneIndex(r53Svc),
+		rrsGetter: NewRecordSetGetter(r53Svc),
+		locks:     newZoneLocks(),
+		logger:    logger,
 	}
 }
 
-func (a *adopter) Adopt(entry *model.Entry) error {
+func (a *adopter) Adopt(ctx context.Context, entry *model.Entry) (*model.Result, error) {
+	if a.cfg.Owners != nil {
+		// The ownership TXT of the entry is set with the assigned owner ID.
+		e := *entry
+		entry = &e
+	}
+	res := &model.Result{
+		Host:   strings.TrimSuffix(entry.Host, "."),
+		Action: model.ActionNone,
+		// Recorded changes are not applied.
+		DryRun: a.cfg.DryRun || a.cfg.Recorder != nil,
+	}
+
 	// Get the right hosted zone.
-	hzid, err := a.findHostedZone(entry.Host)
+	hz, err := a.zones.Find(ctx, entry.Host)
 	if err != nil {
-		return err
+		if err == ErrNoHostedZone {
+			res.Outcome = model.OutcomeNoZone
+			res.Message = fmt.Sprintf("%s for domain %s", err, res.Host)
+			return res, nil
+		}
+		return failed(res, err)
 	}
-	// Can create the txt?
-	err = a.canCreateTXTEntry(hzid, entry.Host)
-	if err != nil {
-		return err
+	hzid := aws.StringValue(hz.Id)
+	res.Zone = strings.TrimSuffix(aws.StringValue(hz.Name), ".")
+	res.ZoneID = hzid
+	if a.cfg.ZoneFilter != nil && !a.cfg.ZoneFilter.MatchString(res.Zone) {
+		res.Outcome = model.OutcomeSkipped
+		res.Message = "hosted zone not matching the zone filter"
+		return res, nil
 	}
 
-	// Create the txt.
-	err = a.createTXTEntry(hzid, entry)
+	// The adoptions on the same hosted zone are serialized so the concurrent change
+	// batches don't collide, only the record sets of the host are listed inside the
+	// lock and the verification waits outside of it.
+	unlock := a.locks.lock(hzid)
+	rrs, txtChanges, err := a.adoptInZone(ctx, hzid, entry, res)
+	unlock()
 	if err != nil {
-		return err
+		return failed(res, err)
 	}
-	return nil
+	if !res.Outcome.Success() {
+		return res, nil
+	}
+
+	// The already owned hosts are verified too, they can be the unverified ones of a
+	// previous run.
+	verifyTXTs := txtValues(txtChanges, entry)
+	if res.Outcome == model.OutcomeAlreadyOwned && len(txtChanges) == 0 {
+		verifyTXTs = a.ownedTXTs(rrs, entry)
+	}
+	if a.cfg.Verifier != nil && !res.DryRun && (res.ChangeID != "" || res.Outcome == model.OutcomeAlreadyOwned) {
+		logger := a.logger.With("hz", hzid).With("host", entry.Host)
+		err := a.cfg.Verifier.Verify(ctx, hzid, res.ChangeID, verifyTXTs)
+		if err != nil {
+			logger.Warningf("txt record set not verified: %s", err)
+			res.Outcome = model.OutcomeUnverified
+			res.Message = err.Error()
+			return res, nil
+		}
+		logger.Infof("txt record set verified")
+	}
+
+	if a.cfg.Annotator != nil {
+		a.cfg.Annotator.Annotate(entry, rrs)
+	}
+	return res, nil
 }
 
-// findHostedZone will find the correct hosted zone for the adopting host.
-func (a *adopter) findHostedZone(domain string) (string, error) {
-	// Get all the available HZ.
-	// TODO: If paginated then get all (more than 100 HZ).
-	req := a.r53Svc.ListHostedZonesRequest(&route53.ListHostedZonesInput{})
-	res, err := req.Send()
+// adoptInZone checks the record sets of the host on the hosted zone and applies (or records)
+// the changes of the adoption, it sets the outcome on the result and returns the record sets
+// of the host and the ownership TXT changes.
+func (a *adopter) adoptInZone(ctx context.Context, hzid string, entry *model.Entry, res *model.Result) ([]route53.ResourceRecordSet, []route53.Change, error) {
+	// Only the record sets of the host and its ownership TXT names, not the whole zone.
+	zonerrs, err := a.rrsGetter.GetRecordSets(ctx, hzid, a.recordNames(entry.Host)...)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+	rrs := recordSetsNamed(zonerrs, entry.Host)
+	res.Records = resultRecords(rrs)
+
+	if a.cfg.Owners != nil {
+		asg, err := a.cfg.Owners.Assign(entry.Host, res.Zone, res.Records)
+		if err != nil {
+			if err == owner.ErrNoRule {
+				res.Outcome = model.OutcomeSkipped
+				res.Message = err.Error()
+				return nil, nil, nil
+			}
+			return nil, nil, err
+		}
+		entry.TXT = registry.NewLabels(asg.OwnerID).String()
+		res.Rule = asg.Rule
+	}
+
+	// Check the alias records and convert if required.
+	logger := a.logger.With("hz", hzid).With("host", entry.Host)
+	if res.Rule != "" {
+		logger = logger.With("rule", res.Rule)
+	}
+	a.reportAliases(rrs, logger)
+	changes := []route53.Change{}
+	if a.cfg.ConvertCNAMEToAlias {
+		changes = a.cnameToAliasChanges(rrs, logger)
+	}
+
+	// Can create the txt?
+	txtChanges, err := a.canCreateTXTEntry(applyChanges(rrs, changes), zonerrs, entry)
+	if err != nil {
+		aerr, ok := err.(*adoptError)
+		if !ok {
+			return nil, nil, err
+		}
+		res.Outcome = aerr.outcome
+		res.Owner = aerr.owner
+		res.Message = aerr.Error()
+		return nil, nil, nil
 	}
-	zones := map[string]route53.HostedZone{}
-	for _, zone := range res.HostedZones {
-		zone := zone
-		name := strings.TrimSuffix(*zone.Name, ".")
-		zones[name] = zone
+
+	// Already adopted, nothing to do.
+	if len(txtChanges) == 0 && len(changes) == 0 {
+		logger.Infof("txt record set already owned")
+		res.Outcome = model.OutcomeAlreadyOwned
+		return rrs, txtChanges, nil
 	}
 
-	// Sanitize domain and get the different subdomain levels.
-	domain = strings.TrimSuffix(domain, ".")
-	splDomain := strings.Split(domain, ".")[1:] // We get rid of the first one (wildcard or direct one)
+	changes = append(changes, txtChanges...)
+	if a.cfg.Recorder != nil {
+		a.cfg.Recorder.Record(&ChangeSet{
+			ZoneID:  hzid,
+			Zone:    res.Zone,
+			Host:    res.Host,
+			Owner:   entryOwner(entry),
+			Rule:    res.Rule,
+			Changes: changes,
+			Current: currentRecordSets(zonerrs, entry.Host, changes),
+		})
+		logger.Infof("txt record set changes recorded")
+	} else {
+		// Create the txt.
+		res.ChangeID, err = a.createTXTEntry(ctx, hzid, entry, changes)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
-	// Get the correct hosted zone. On each iteration it will remove a subdomain level
-	// until it finds the zone.
-	for i := 0; i < len(splDomain)-1; i++ {
-		// Generate domain.
-		domain := strings.Join(splDomain[i:], ".")
+	res.Outcome = model.OutcomeAdopted
+	if len(txtChanges) == 0 {
+		res.Outcome = model.OutcomeAlreadyOwned
+	} else {
+		res.Action = model.ActionCreate
+	}
+	for _, ch := range txtChanges {
+		if ch.Action == route53.ChangeActionUpsert {
+			res.Action = model.ActionMerge
+			res.Message = "ownership merged into the existing txt record set"
+		}
+	}
+	res.TXTs = resultTXTs(txtChanges, entry)
+	return rrs, txtChanges, nil
+}
 
-		// If HZ found then finish.
-		if zone, ok := zones[domain]; ok {
-			return *zone.Id, nil
+// resultTXTs returns the ownership TXT records written by the changes, one per record set.
+func resultTXTs(changes []route53.Change, entry *model.Entry) []model.TXT {
+	txt := fmt.Sprintf(`"%s"`, strings.Trim(entry.TXT, `"`))
+	res := []model.TXT{}
+	for _, ch := range changes {
+		if ch.ResourceRecordSet.Type != route53.RRTypeTxt {
+			continue
 		}
+		res = append(res, model.TXT{
+			Name:          aws.StringValue(ch.ResourceRecordSet.Name),
+			SetIdentifier: aws.StringValue(ch.ResourceRecordSet.SetIdentifier),
+			Value:         txt,
+			Merged:        ch.Action == route53.ChangeActionUpsert,
+		})
 	}
+	return res
+}
 
-	return "", fmt.Errorf("no hosted zones available for domain %s", domain)
+// recordNames returns the names of the record sets of the host and its ownership TXT records.
+func (a *adopter) recordNames(host string) []string {
+	host = strings.TrimSuffix(host, ".")
+	names := []string{host}
+	for _, t := range []route53.RRType{route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname} {
+		names = append(names, a.cfg.Naming.TXTName(host, string(t)))
+	}
+	return names
 }
 
-func (a *adopter) canCreateTXTEntry(hzID, domain string) error {
-	rrs, err := a.getRecordSets(hzID, domain)
+// entryOwner returns the owner ID of the ownership TXT of the entry.
+func entryOwner(entry *model.Entry) string {
+	labels, err := registry.ParseTXT(entry.TXT)
 	if err != nil {
-		return err
+		return ""
+	}
+	return labels.Owner()
+}
+
+// failed sets the result as an unexpected failure.
+func failed(res *model.Result, err error) (*model.Result, error) {
+	res.Outcome = model.OutcomeError
+	res.Message = err.Error()
+	res.Error = err.Error()
+	return res, err
+}
+
+// adoptError is an adoption failure with a known outcome.
+type adoptError struct {
+	outcome model.Outcome
+	owner   string
+	msg     string
+}
+
+func newAdoptError(outcome model.Outcome, format string, args ...interface{}) *adoptError {
+	return &adoptError{
+		outcome: outcome,
+		msg:     fmt.Sprintf(format, args...),
 	}
+}
+
+func (e *adoptError) Error() string {
+	return e.msg
+}
+
+// canCreateTXTEntry checks if the host can be adopted and returns the changes of the ownership TXT
+// record sets, external-dns expects one TXT for each set identifier with the same routing policy
+// of the record (and for each record type when the registry names are type prefixed).
+// The TXT record sets that already exist with the same owner don't have changes, the ones that block
+// the adoption are returned as an adoptError with the outcome.
+func (a *adopter) canCreateTXTEntry(rrs, zonerrs []route53.ResourceRecordSet, entry *model.Entry) ([]route53.Change, error) {
+	domain := entry.Host
 
 	// Check the host exists.
 	ts := []route53.RRType{
@@ -99,106 +314,275 @@ func (a *adopter) canCreateTXTEntry(hzID, domain string) error {
 		route53.RRTypeAaaa,
 		route53.RRTypeCname,
 	}
-	found := a.findRecordSetType(ts, rrs)
-	if !found {
-		return fmt.Errorf("not present record set for A, AAAA or CNAME types with host %s", domain)
+	hostrrs := a.filterRecordSetType(ts, rrs)
+	if len(hostrrs) == 0 {
+		return nil, newAdoptError(model.OutcomeMissingRecord, "not present record set for A, AAAA or CNAME types with host %s", domain)
 	}
 
-	// Check the host txt exists.
-	ts = []route53.RRType{route53.RRTypeTxt}
-	found = a.findRecordSetType(ts, rrs)
-	if found {
-		return fmt.Errorf("txt record set already present for domain: %s", domain)
+	// Check the routing policies are supported by external-dns.
+	for _, rs := range hostrrs {
+		if aws.BoolValue(rs.MultiValueAnswer) {
+			return nil, newAdoptError(model.OutcomeUnsupported, "unsupported multivalue answer routing policy on %s record set with host %s", rs.Type, domain)
+		}
+		if rs.TrafficPolicyInstanceId != nil {
+			return nil, newAdoptError(model.OutcomeUnsupported, "unsupported traffic policy on %s record set with host %s", rs.Type, domain)
+		}
 	}
 
-	return nil
-}
+	// Check the host txt exists for each set identifier (simple routing records don't have one).
+	txt := fmt.Sprintf(`"%s"`, strings.Trim(entry.TXT, `"`))
+	owner := entryOwner(entry)
+	res := []route53.Change{}
+	seen := map[string]bool{}
+	for _, rs := range hostrrs {
+		name := a.cfg.Naming.TXTName(strings.TrimSuffix(domain, "."), string(rs.Type))
+		id := aws.StringValue(rs.SetIdentifier)
+		if seen[name+"/"+id] {
+			continue
+		}
+		seen[name+"/"+id] = true
 
-func (a *adopter) findRecordSetType(types []route53.RRType, rrs []route53.ResourceRecordSet) bool {
-	for _, rr := range rrs {
-		for _, t := range types {
-			if rr.Type == t {
-				return true
+		txtrrs := a.filterRecordSetType([]route53.RRType{route53.RRTypeTxt}, recordSetsNamed(zonerrs, name))
+		existing := false
+		for _, txtrs := range txtrrs {
+			if aws.StringValue(txtrs.SetIdentifier) != id {
+				continue
+			}
+			existing = true
+
+			// Route53 only allows one TXT record set per name, so the ownership
+			// needs to be another value of the existing one.
+			if _, ok := registry.TXTOwner(txtrs); !ok && a.cfg.MergeExistingTXT {
+				res = append(res, route53.Change{
+					Action:            route53.ChangeActionUpsert,
+					ResourceRecordSet: mergeTXT(txtrs, txt),
+				})
+				continue
 			}
+			err := checkExistingTXT(txtrs, owner, domain)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if existing {
+			continue
 		}
+
+		res = append(res, route53.Change{
+			Action: route53.ChangeActionCreate,
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				Name: aws.String(name),
+				Type: route53.RRTypeTxt,
+				TTL:  aws.Int64(300),
+				ResourceRecords: []route53.ResourceRecord{
+					route53.ResourceRecord{
+						Value: aws.String(txt),
+					},
+				},
+				// Same routing policy as the record.
+				SetIdentifier: rs.SetIdentifier,
+				Weight:        rs.Weight,
+				Region:        rs.Region,
+				Failover:      rs.Failover,
+				GeoLocation:   rs.GeoLocation,
+			},
+		})
 	}
 
-	return false
+	return res, nil
 }
 
-func (a *adopter) getRecordSets(hzID, domain string) ([]route53.ResourceRecordSet, error) {
-	rrs := []route53.ResourceRecordSet{}
-	domain = strings.TrimRight(domain, ".") + "." // Set always the dot at the end.
+// checkExistingTXT checks the TXT record set that already exists on the ownership record name
+// of the host, only the ones owned by the same owner don't block the adoption.
+func checkExistingTXT(rs route53.ResourceRecordSet, owner, domain string) error {
+	setID := ""
+	if id := aws.StringValue(rs.SetIdentifier); id != "" {
+		setID = fmt.Sprintf(" (set identifier %s)", id)
+	}
 
-	params := &route53.ListResourceRecordSetsInput{
-		HostedZoneId: aws.String(hzID),
+	txtOwner, ok := registry.TXTOwner(rs)
+	switch {
+	case !ok:
+		return newAdoptError(model.OutcomeExistingTXT, "txt record set already present for domain: %s%s, it's not an external-dns registry record", domain, setID)
+	case txtOwner != owner:
+		err := newAdoptError(model.OutcomeOwnerConflict, "txt record set already present for domain: %s%s, owned by %q", domain, setID, txtOwner)
+		err.owner = txtOwner
+		return err
 	}
-	for {
-		req := a.r53Svc.ListResourceRecordSetsRequest(params)
-		resp, err := req.Send()
-		if err != nil {
-			return nil, err
+
+	return nil
+}
+
+// txtValues returns the ownership TXT values that the changes create.
+func txtValues(changes []route53.Change, entry *model.Entry) []verify.TXT {
+	txt := fmt.Sprintf(`"%s"`, strings.Trim(entry.TXT, `"`))
+	res := []verify.TXT{}
+	seen := map[string]bool{}
+	for _, ch := range changes {
+		name := aws.StringValue(ch.ResourceRecordSet.Name)
+		if ch.ResourceRecordSet.Type != route53.RRTypeTxt || seen[name] {
+			continue
 		}
+		seen[name] = true
+		res = append(res, verify.TXT{Name: name, Value: txt})
+	}
+	return res
+}
 
-		// Save current records.
-		for _, rs := range resp.ResourceRecordSets {
-			if aws.StringValue(rs.Name) == domain {
-				rrs = append(rrs, rs)
-			}
+// ownedTXTs returns the ownership TXT values of the existing TXT records of the host record sets.
+func (a *adopter) ownedTXTs(rrs []route53.ResourceRecordSet, entry *model.Entry) []verify.TXT {
+	txt := fmt.Sprintf(`"%s"`, strings.Trim(entry.TXT, `"`))
+	host := strings.TrimSuffix(entry.Host, ".")
+	res := []verify.TXT{}
+	seen := map[string]bool{}
+	for _, rs := range a.filterRecordSetType([]route53.RRType{route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname}, rrs) {
+		name := a.cfg.Naming.TXTName(host, string(rs.Type))
+		if seen[name] {
+			continue
 		}
+		seen[name] = true
+		res = append(res, verify.TXT{Name: name, Value: txt})
+	}
+	return res
+}
 
-		// No more? then exit loop.
-		if !aws.BoolValue(resp.IsTruncated) {
-			break
+// mergeTXT returns the TXT record set with the ownership value added. The ownership value goes
+// first because external-dns only reads the first value, the existing values are kept untouched.
+func mergeTXT(rs route53.ResourceRecordSet, txt string) *route53.ResourceRecordSet {
+	merged := rs
+	merged.ResourceRecords = []route53.ResourceRecord{{Value: aws.String(txt)}}
+	merged.ResourceRecords = append(merged.ResourceRecords, rs.ResourceRecords...)
+	return &merged
+}
+
+// txtDiff returns the values of a merged TXT record set marking the added ownership value.
+func txtDiff(rs *route53.ResourceRecordSet, txt string) string {
+	lines := []string{}
+	for _, r := range rs.ResourceRecords {
+		v := aws.StringValue(r.Value)
+		if v == txt {
+			lines = append(lines, "+ "+v)
+			continue
 		}
+		lines = append(lines, "  "+v)
+	}
+	return strings.Join(lines, "\n")
+}
 
-		// prepare the call to grab the next ones.
-		params.StartRecordName = resp.NextRecordName
+func (a *adopter) filterRecordSetType(types []route53.RRType, rrs []route53.ResourceRecordSet) []route53.ResourceRecordSet {
+	res := []route53.ResourceRecordSet{}
+	for _, rr := range rrs {
+		for _, t := range types {
+			if rr.Type == t {
+				res = append(res, rr)
+				break
+			}
+		}
 	}
 
-	return rrs, nil
+	return res
 }
 
-func (a *adopter) createTXTEntry(hzID string, entry *model.Entry) error {
-	// Ensure string is between quotes.
-	txt := fmt.Sprintf(`"%s"`, strings.Trim(entry.TXT, `"`))
-
+// createTXTEntry creates the ownership TXT record sets, all the changes are sent in the same change batch.
+// It returns the ID of the Route53 change.
+func (a *adopter) createTXTEntry(ctx context.Context, hzID string, entry *model.Entry, changes []route53.Change) (string, error) {
 	logger := a.logger.With("hz", hzID).
 		With("host", entry.Host).
 		With("txt", entry.TXT)
-	if a.dryRun {
+
+	txt := fmt.Sprintf(`"%s"`, strings.Trim(entry.TXT, `"`))
+	for _, ch := range changes {
+		if ch.Action == route53.ChangeActionUpsert && ch.ResourceRecordSet.Type == route53.RRTypeTxt {
+			logger.Infof("existing %s txt record set values will be:\n%s", aws.StringValue(ch.ResourceRecordSet.Name), txtDiff(ch.ResourceRecordSet, txt))
+		}
+	}
+
+	if a.cfg.DryRun {
 		logger.Infof("not creating txt record set because of dry-run")
-		return nil
+		return "", nil
+	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("txt record set not created: %s", err)
 	}
 
 	input := &route53.ChangeResourceRecordSetsInput{
 		ChangeBatch: &route53.ChangeBatch{
-			Changes: []route53.Change{
-				{
-					Action: route53.ChangeActionCreate,
-					ResourceRecordSet: &route53.ResourceRecordSet{
-						Name: aws.String(entry.Host),
-						Type: route53.RRTypeTxt,
-						TTL:  aws.Int64(300),
-						ResourceRecords: []route53.ResourceRecord{
-							route53.ResourceRecord{
-								Value: aws.String(txt),
-							},
-						},
-					},
-				},
-			},
+			Changes: changes,
 			Comment: aws.String("Add txt entry"),
 		},
 		HostedZoneId: aws.String(hzID),
 	}
 
 	req := a.r53Svc.ChangeResourceRecordSetsRequest(input)
-	_, err := req.Send()
+	req.SetContext(ctx)
+	resp, err := req.Send()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	logger.Infof("txt record set created")
-	return nil
+	if resp == nil || resp.ChangeInfo == nil {
+		return "", nil
+	}
+	return aws.StringValue(resp.ChangeInfo.Id), nil
+}
+
+// resultRecords returns the record sets of the host as result records.
+func resultRecords(rrs []route53.ResourceRecordSet) []model.Record {
+	res := []model.Record{}
+	for _, rs := range rrs {
+		r := model.Record{
+			Type:          string(rs.Type),
+			SetIdentifier: aws.StringValue(rs.SetIdentifier),
+			TTL:           aws.Int64Value(rs.TTL),
+		}
+		for _, rr := range rs.ResourceRecords {
+			r.Values = append(r.Values, aws.StringValue(rr.Value))
+		}
+		if rs.AliasTarget != nil {
+			r.Alias = aws.StringValue(rs.AliasTarget.DNSName)
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+// recordSetsNamed returns the record sets with the name.
+func recordSetsNamed(rrs []route53.ResourceRecordSet, name string) []route53.ResourceRecordSet {
+	name = strings.TrimRight(name, ".") + "." // Set always the dot at the end.
+
+	res := []route53.ResourceRecordSet{}
+	for _, rs := range rrs {
+		if aws.StringValue(rs.Name) == name {
+			res = append(res, rs)
+		}
+	}
+
+	return res
+}
+
+// applyChanges returns the record sets after applying the changes.
+func applyChanges(rrs []route53.ResourceRecordSet, changes []route53.Change) []route53.ResourceRecordSet {
+	res := []route53.ResourceRecordSet{}
+	for _, rs := range rrs {
+		deleted := false
+		for _, ch := range changes {
+			if ch.Action == route53.ChangeActionDelete && ch.ResourceRecordSet.Type == rs.Type &&
+				aws.StringValue(ch.ResourceRecordSet.SetIdentifier) == aws.StringValue(rs.SetIdentifier) {
+				deleted = true
+				break
+			}
+		}
+		if !deleted {
+			res = append(res, rs)
+		}
+	}
+
+	for _, ch := range changes {
+		if ch.Action == route53.ChangeActionCreate {
+			res = append(res, *ch.ResourceRecordSet)
+		}
+	}
+
+	return res
 }
@@ -1,6 +1,10 @@
 package adopt_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -10,8 +14,13 @@ import (
 
 	"github.com/slok/external-dns-aws-migrator/pkg/log"
 	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	mowner "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/owner"
+	mverify "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/verify"
 	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
 	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/owner"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/verify"
 )
 
 func mockDefaultListHostedZones() route53.ListHostedZonesRequest {
@@ -39,7 +48,8 @@ func mockDefaultListHostedZones() route53.ListHostedZonesRequest {
 func mockListHostedZones(v *route53.ListHostedZonesOutput) route53.ListHostedZonesRequest {
 	return route53.ListHostedZonesRequest{
 		Request: &aws.Request{
-			Data: v,
+			HTTPRequest: &http.Request{},
+			Data:        v,
 		},
 	}
 }
@@ -58,16 +68,35 @@ func mockDefaultListResourceRecordSetsRequest() route53.ListResourceRecordSetsRe
 			Name: aws.String("valid.without.txt.batman.dc.superheroes.comics."),
 			Type: route53.RRTypeA,
 		}
+		rrs3 = route53.ResourceRecordSet{
+			Name: aws.String("owned.batman.dc.superheroes.comics."),
+			Type: route53.RRTypeA,
+		}
+		rrs4 = route53.ResourceRecordSet{
+			Name:            aws.String("owned.batman.dc.superheroes.comics."),
+			Type:            route53.RRTypeTxt,
+			ResourceRecords: []route53.ResourceRecord{{Value: aws.String(`"heritage=external-dns,external-dns/owner=default"`)}},
+		}
+		rrs5 = route53.ResourceRecordSet{
+			Name: aws.String("owned.by.joker.batman.dc.superheroes.comics."),
+			Type: route53.RRTypeA,
+		}
+		rrs6 = route53.ResourceRecordSet{
+			Name:            aws.String("owned.by.joker.batman.dc.superheroes.comics."),
+			Type:            route53.RRTypeTxt,
+			ResourceRecords: []route53.ResourceRecord{{Value: aws.String(`"heritage=external-dns,external-dns/owner=joker"`)}},
+		}
 	)
 	return mockListResourceRecordSetsRequest(&route53.ListResourceRecordSetsOutput{
-		ResourceRecordSets: []route53.ResourceRecordSet{rrs0, rrs1, rrs2},
+		ResourceRecordSets: []route53.ResourceRecordSet{rrs0, rrs1, rrs2, rrs3, rrs4, rrs5, rrs6},
 	})
 }
 
 func mockListResourceRecordSetsRequest(v *route53.ListResourceRecordSetsOutput) route53.ListResourceRecordSetsRequest {
 	return route53.ListResourceRecordSetsRequest{
 		Request: &aws.Request{
-			Data: v,
+			HTTPRequest: &http.Request{},
+			Data:        v,
 		},
 	}
 }
@@ -75,7 +104,8 @@ func mockListResourceRecordSetsRequest(v *route53.ListResourceRecordSetsOutput)
 func mockChangeResourceRecordSetsRequest(v *route53.ChangeResourceRecordSetsOutput) route53.ChangeResourceRecordSetsRequest {
 	return route53.ChangeResourceRecordSetsRequest{
 		Request: &aws.Request{
-			Data: v,
+			Data:        v,
+			HTTPRequest: &http.Request{},
 		},
 	}
 }
@@ -118,38 +148,59 @@ func TestAdopterAdopt(t *testing.T) {
 	tests := []struct {
 		name         string
 		dryRun       bool
+		txtPrefix    string
 		entry        *model.Entry
 		expEntryHZ   string
 		expEntryTXT  string
 		expEntryHost string
-		expErr       bool
+		expOutcome   model.Outcome
+		expOwner     string
 	}{
 		{
-			name:   "If there is not valid HZ for the domain it should error.",
+			name:   "If there is not valid HZ for the domain it should fail with no zone.",
 			dryRun: false,
 			entry: &model.Entry{
 				Host: "domain.with.no.hosted-zone.com",
 				TXT:  "heritage=external-dns,external-dns/owner=default",
 			},
-			expErr: true,
+			expOutcome: model.OutcomeNoZone,
 		},
 		{
-			name:   "If there is not a A, AAAA or CNAME entry with the host on the HZ it should fail.",
+			name:   "If there is not a A, AAAA or CNAME entry with the host on the HZ it should fail with missing record.",
 			dryRun: false,
 			entry: &model.Entry{
 				Host: "no.a.aaaa.or.cname.entry.batman.dc.superheroes.comics",
 				TXT:  "heritage=external-dns,external-dns/owner=default",
 			},
-			expErr: true,
+			expOutcome: model.OutcomeMissingRecord,
 		},
 		{
-			name:   "If there is a A, AAAA or CNAME already with the host and also a TXT it should fail.",
+			name:   "If there is a A, AAAA or CNAME already with the host and also a non registry TXT it should fail with existing TXT.",
 			dryRun: false,
 			entry: &model.Entry{
 				Host: "valid.with.txt.batman.dc.superheroes.comics",
 				TXT:  "heritage=external-dns,external-dns/owner=default",
 			},
-			expErr: true,
+			expOutcome: model.OutcomeExistingTXT,
+		},
+		{
+			name:   "If there is a A, AAAA or CNAME already with the host and a TXT of the same owner it should be already owned.",
+			dryRun: false,
+			entry: &model.Entry{
+				Host: "owned.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			},
+			expOutcome: model.OutcomeAlreadyOwned,
+		},
+		{
+			name:   "If there is a A, AAAA or CNAME already with the host and a TXT of other owner it should fail with owner conflict.",
+			dryRun: false,
+			entry: &model.Entry{
+				Host: "owned.by.joker.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			},
+			expOutcome: model.OutcomeOwnerConflict,
+			expOwner:   "joker",
 		},
 		{
 			name:   "If there is a A, AAAA or CNAME already with the host and not a TXT it should create the entry.",
@@ -161,7 +212,20 @@ func TestAdopterAdopt(t *testing.T) {
 			expEntryHZ:   "batman.dc.superheroes.comics.",
 			expEntryTXT:  `"heritage=external-dns,external-dns/owner=default"`,
 			expEntryHost: "valid.without.txt.batman.dc.superheroes.comics",
-			expErr:       false,
+			expOutcome:   model.OutcomeAdopted,
+		},
+		{
+			name:      "If there is a A, AAAA or CNAME already with the host and a TXT but not a prefixed TXT it should create the prefixed entry.",
+			dryRun:    false,
+			txtPrefix: "registry-",
+			entry: &model.Entry{
+				Host: "valid.with.txt.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			},
+			expEntryHZ:   "batman.dc.superheroes.comics.",
+			expEntryTXT:  `"heritage=external-dns,external-dns/owner=default"`,
+			expEntryHost: "registry-valid.with.txt.batman.dc.superheroes.comics",
+			expOutcome:   model.OutcomeAdopted,
 		},
 		{
 			name:   "If there is a A, AAAA or CNAME already with the host and not a TXT in dry run mode it shouldn't create the entry.",
@@ -173,7 +237,7 @@ func TestAdopterAdopt(t *testing.T) {
 			expEntryHZ:   "batman.dc.superheroes.comics.",
 			expEntryTXT:  `"heritage=external-dns,external-dns/owner=default"`,
 			expEntryHost: "valid.without.txt.batman.dc.superheroes.comics",
-			expErr:       false,
+			expOutcome:   model.OutcomeAdopted,
 		},
 	}
 
@@ -187,22 +251,562 @@ func TestAdopterAdopt(t *testing.T) {
 			// Mock hosted zones.
 			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
 			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockDefaultListResourceRecordSetsRequest())
-			// Only for no dry run.
-			if !test.dryRun {
+			// Only for no dry run adoptions.
+			if !test.dryRun && test.expOutcome == model.OutcomeAdopted {
 				mbf := getTXTResourceRecordSetMatchedByFunc(test.expEntryTXT, test.expEntryHZ, test.expEntryHost)
 				mr53.On("ChangeResourceRecordSetsRequest", mock.MatchedBy(mbf)).Return(mockChangeResourceRecordSetsRequest(nil))
 			}
 
-			ad := adopt.NewRSAdopter(test.dryRun, mr53, log.Dummy)
+			ad := adopt.NewRSAdopter(adopt.Config{
+				DryRun: test.dryRun,
+				Naming: registry.Naming{Prefix: test.txtPrefix},
+			}, mr53, log.Dummy)
 
-			err := ad.Adopt(test.entry)
-			if test.expErr {
-				assert.Error(err)
-			} else if assert.NoError(err) {
-				// In the happy path assert the expectations.
+			res, err := ad.Adopt(context.Background(), test.entry)
+			if assert.NoError(err) {
+				assert.Equal(test.expOutcome, res.Outcome)
+				assert.Equal(test.expOwner, res.Owner)
+				assert.Equal(test.dryRun, res.DryRun)
+				if test.expOutcome == model.OutcomeAdopted {
+					mr53.AssertExpectations(t)
+				} else {
+					mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+				}
+			}
+		})
+	}
+
+}
+
+func TestAdopterAdoptRoutingPolicies(t *testing.T) {
+	tests := []struct {
+		name       string
+		rrs        []route53.ResourceRecordSet
+		expSetIDs  []string
+		expOutcome model.Outcome
+	}{
+		{
+			name: "Weighted record sets should create a TXT for each set identifier with the same routing policy.",
+			rrs: []route53.ResourceRecordSet{
+				{Name: aws.String("weighted.batman.dc.superheroes.comics."), Type: route53.RRTypeCname, SetIdentifier: aws.String("blue"), Weight: aws.Int64(90)},
+				{Name: aws.String("weighted.batman.dc.superheroes.comics."), Type: route53.RRTypeCname, SetIdentifier: aws.String("green"), Weight: aws.Int64(10)},
+			},
+			expSetIDs:  []string{"blue", "green"},
+			expOutcome: model.OutcomeAdopted,
+		},
+		{
+			name: "Failover record sets with a TXT already on one of the set identifiers should fail.",
+			rrs: []route53.ResourceRecordSet{
+				{Name: aws.String("weighted.batman.dc.superheroes.comics."), Type: route53.RRTypeA, SetIdentifier: aws.String("primary"), Failover: route53.ResourceRecordSetFailoverPrimary},
+				{Name: aws.String("weighted.batman.dc.superheroes.comics."), Type: route53.RRTypeA, SetIdentifier: aws.String("secondary"), Failover: route53.ResourceRecordSetFailoverSecondary},
+				{Name: aws.String("weighted.batman.dc.superheroes.comics."), Type: route53.RRTypeTxt, SetIdentifier: aws.String("secondary"), Failover: route53.ResourceRecordSetFailoverSecondary},
+			},
+			expOutcome: model.OutcomeExistingTXT,
+		},
+		{
+			name: "Multivalue answer record sets should fail as unsupported.",
+			rrs: []route53.ResourceRecordSet{
+				{Name: aws.String("weighted.batman.dc.superheroes.comics."), Type: route53.RRTypeA, SetIdentifier: aws.String("a"), MultiValueAnswer: aws.Bool(true)},
+			},
+			expOutcome: model.OutcomeUnsupported,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockListResourceRecordSetsRequest(&route53.ListResourceRecordSetsOutput{
+				ResourceRecordSets: test.rrs,
+			}))
+			mbf := func(input *route53.ChangeResourceRecordSetsInput) bool {
+				if len(input.ChangeBatch.Changes) != len(test.expSetIDs) {
+					return false
+				}
+				for i, ch := range input.ChangeBatch.Changes {
+					rs := ch.ResourceRecordSet
+					if rs.Type != route53.RRTypeTxt || aws.StringValue(rs.SetIdentifier) != test.expSetIDs[i] {
+						return false
+					}
+					// Same routing policy.
+					if aws.Int64Value(rs.Weight) != aws.Int64Value(test.rrs[i].Weight) {
+						return false
+					}
+				}
+				return true
+			}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.MatchedBy(mbf)).Return(mockChangeResourceRecordSetsRequest(nil))
+
+			ad := adopt.NewRSAdopter(adopt.Config{}, mr53, log.Dummy)
+			res, err := ad.Adopt(context.Background(), &model.Entry{
+				Host: "weighted.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			})
+			if assert.NoError(err) {
+				assert.Equal(test.expOutcome, res.Outcome)
+				if test.expOutcome == model.OutcomeAdopted {
+					mr53.AssertExpectations(t)
+
+					// The written TXTs have the set identifier so they can be rolled back.
+					gotSetIDs := []string{}
+					for _, txt := range res.TXTs {
+						gotSetIDs = append(gotSetIDs, txt.SetIdentifier)
+					}
+					assert.Equal(test.expSetIDs, gotSetIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestAdopterAdoptConvertCNAMEToAlias(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		expActions []route53.ChangeAction
+		expTypes   []route53.RRType
+		expZone    string
+	}{
+		{
+			name:       "A CNAME to a load balancer should be converted to an alias in the same change batch of the TXT.",
+			target:     "my-lb-123.eu-west-1.elb.amazonaws.com",
+			expActions: []route53.ChangeAction{route53.ChangeActionDelete, route53.ChangeActionCreate, route53.ChangeActionCreate},
+			expTypes:   []route53.RRType{route53.RRTypeCname, route53.RRTypeA, route53.RRTypeTxt},
+			expZone:    "Z32O12XQLNTSW2",
+		},
+		{
+			name:       "A CNAME to a dualstack load balancer should be converted to A and AAAA aliases.",
+			target:     "dualstack.my-lb-123.eu-west-1.elb.amazonaws.com",
+			expActions: []route53.ChangeAction{route53.ChangeActionDelete, route53.ChangeActionCreate, route53.ChangeActionCreate, route53.ChangeActionCreate},
+			expTypes:   []route53.RRType{route53.RRTypeCname, route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeTxt},
+			expZone:    "Z32O12XQLNTSW2",
+		},
+		{
+			name:       "A CNAME to a Beijing load balancer should be converted to an alias on the Beijing hosted zone.",
+			target:     "my-lb-123.cn-north-1.elb.amazonaws.com.cn",
+			expActions: []route53.ChangeAction{route53.ChangeActionDelete, route53.ChangeActionCreate, route53.ChangeActionCreate},
+			expTypes:   []route53.RRType{route53.RRTypeCname, route53.RRTypeA, route53.RRTypeTxt},
+			expZone:    "Z1GDH35T77C1KE",
+		},
+		{
+			name:       "A CNAME to a Ningxia load balancer should be converted to an alias on the Ningxia hosted zone.",
+			target:     "my-lb-123.cn-northwest-1.elb.amazonaws.com.cn",
+			expActions: []route53.ChangeAction{route53.ChangeActionDelete, route53.ChangeActionCreate, route53.ChangeActionCreate},
+			expTypes:   []route53.RRType{route53.RRTypeCname, route53.RRTypeA, route53.RRTypeTxt},
+			expZone:    "ZM7IZAIOVVDZF",
+		},
+		{
+			name:       "A CNAME to a non load balancer should not be converted.",
+			target:     "batcave.gotham.com",
+			expActions: []route53.ChangeAction{route53.ChangeActionCreate},
+			expTypes:   []route53.RRType{route53.RRTypeTxt},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockListResourceRecordSetsRequest(&route53.ListResourceRecordSetsOutput{
+				ResourceRecordSets: []route53.ResourceRecordSet{
+					{
+						Name:            aws.String("lb.batman.dc.superheroes.comics."),
+						Type:            route53.RRTypeCname,
+						TTL:             aws.Int64(300),
+						ResourceRecords: []route53.ResourceRecord{{Value: aws.String(test.target)}},
+					},
+				},
+			}))
+			mbf := func(input *route53.ChangeResourceRecordSetsInput) bool {
+				if len(input.ChangeBatch.Changes) != len(test.expActions) {
+					return false
+				}
+				for i, ch := range input.ChangeBatch.Changes {
+					if ch.Action != test.expActions[i] || ch.ResourceRecordSet.Type != test.expTypes[i] {
+						return false
+					}
+					if ch.Action == route53.ChangeActionCreate && ch.ResourceRecordSet.Type != route53.RRTypeTxt &&
+						aws.StringValue(ch.ResourceRecordSet.AliasTarget.HostedZoneId) != test.expZone {
+						return false
+					}
+				}
+				return true
+			}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.MatchedBy(mbf)).Return(mockChangeResourceRecordSetsRequest(nil))
+
+			ad := adopt.NewRSAdopter(adopt.Config{ConvertCNAMEToAlias: true}, mr53, log.Dummy)
+			res, err := ad.Adopt(context.Background(), &model.Entry{
+				Host: "lb.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			})
+			if assert.NoError(err) {
+				assert.Equal(model.OutcomeAdopted, res.Outcome)
 				mr53.AssertExpectations(t)
 			}
 		})
 	}
+}
+
+func TestAdopterAdoptMergeExistingTXT(t *testing.T) {
+	tests := []struct {
+		name       string
+		merge      bool
+		dryRun     bool
+		txt        route53.ResourceRecordSet
+		expOutcome model.Outcome
+		expValues  []string
+	}{
+		{
+			name: "Merging an existing non registry TXT should upsert it with the ownership value and the existing values untouched.",
+			txt: route53.ResourceRecordSet{
+				Name: aws.String("spf.batman.dc.superheroes.comics."),
+				Type: route53.RRTypeTxt,
+				TTL:  aws.Int64(3600),
+				ResourceRecords: []route53.ResourceRecord{
+					{Value: aws.String(`"v=spf1 include:_spf.gotham.com  -all"`)},
+					{Value: aws.String(`"google-site-verification=batcave"`)},
+				},
+			},
+			merge:      true,
+			expOutcome: model.OutcomeAdopted,
+			expValues: []string{
+				`"heritage=external-dns,external-dns/owner=default"`,
+				`"v=spf1 include:_spf.gotham.com  -all"`,
+				`"google-site-verification=batcave"`,
+			},
+		},
+		{
+			name: "Merging in dry-run mode shouldn't change anything.",
+			txt: route53.ResourceRecordSet{
+				Name:            aws.String("spf.batman.dc.superheroes.comics."),
+				Type:            route53.RRTypeTxt,
+				ResourceRecords: []route53.ResourceRecord{{Value: aws.String(`"v=spf1 -all"`)}},
+			},
+			merge:      true,
+			dryRun:     true,
+			expOutcome: model.OutcomeAdopted,
+		},
+		{
+			name: "Without merging an existing non registry TXT should fail.",
+			txt: route53.ResourceRecordSet{
+				Name:            aws.String("spf.batman.dc.superheroes.comics."),
+				Type:            route53.RRTypeTxt,
+				ResourceRecords: []route53.ResourceRecord{{Value: aws.String(`"v=spf1 -all"`)}},
+			},
+			expOutcome: model.OutcomeExistingTXT,
+		},
+		{
+			name: "Merging an existing TXT of other owner should fail.",
+			txt: route53.ResourceRecordSet{
+				Name:            aws.String("spf.batman.dc.superheroes.comics."),
+				Type:            route53.RRTypeTxt,
+				ResourceRecords: []route53.ResourceRecord{{Value: aws.String(`"heritage=external-dns,external-dns/owner=joker"`)}},
+			},
+			merge:      true,
+			expOutcome: model.OutcomeOwnerConflict,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockListResourceRecordSetsRequest(&route53.ListResourceRecordSetsOutput{
+				ResourceRecordSets: []route53.ResourceRecordSet{
+					{Name: aws.String("spf.batman.dc.superheroes.comics."), Type: route53.RRTypeA},
+					test.txt,
+				},
+			}))
+			mbf := func(input *route53.ChangeResourceRecordSetsInput) bool {
+				if len(input.ChangeBatch.Changes) != 1 {
+					return false
+				}
+				ch := input.ChangeBatch.Changes[0]
+				if ch.Action != route53.ChangeActionUpsert || aws.Int64Value(ch.ResourceRecordSet.TTL) != aws.Int64Value(test.txt.TTL) {
+					return false
+				}
+				gotValues := []string{}
+				for _, r := range ch.ResourceRecordSet.ResourceRecords {
+					gotValues = append(gotValues, aws.StringValue(r.Value))
+				}
+				return assert.Equal(test.expValues, gotValues)
+			}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.MatchedBy(mbf)).Return(mockChangeResourceRecordSetsRequest(nil))
+
+			ad := adopt.NewRSAdopter(adopt.Config{MergeExistingTXT: test.merge, DryRun: test.dryRun}, mr53, log.Dummy)
+			res, err := ad.Adopt(context.Background(), &model.Entry{
+				Host: "spf.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			})
+			if assert.NoError(err) {
+				assert.Equal(test.expOutcome, res.Outcome)
+				if len(test.expValues) > 0 {
+					mr53.AssertExpectations(t)
+				} else {
+					mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+				}
+			}
+		})
+	}
+}
+
+func TestAdopterAdoptVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		verifyErr  error
+		expOutcome model.Outcome
+	}{
+		{
+			name:       "A verified adoption should be adopted.",
+			expOutcome: model.OutcomeAdopted,
+		},
+		{
+			name:       "A not verified adoption should be unverified.",
+			verifyErr:  errors.New("wanted error"),
+			expOutcome: model.OutcomeUnverified,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
 
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockDefaultListResourceRecordSetsRequest())
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Return(mockChangeResourceRecordSetsRequest(&route53.ChangeResourceRecordSetsOutput{
+				ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/1"), Status: route53.ChangeStatusPending},
+			}))
+			mv := &mverify.Verifier{}
+			expTXTs := []verify.TXT{{Name: "valid.without.txt.batman.dc.superheroes.comics", Value: `"heritage=external-dns,external-dns/owner=default"`}}
+			mv.On("Verify", mock.Anything, "batman.dc.superheroes.comics.", "/change/1", expTXTs).Once().Return(test.verifyErr)
+
+			ad := adopt.NewRSAdopter(adopt.Config{Verifier: mv}, mr53, log.Dummy)
+			res, err := ad.Adopt(context.Background(), &model.Entry{
+				Host: "valid.without.txt.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			})
+			if assert.NoError(err) {
+				assert.Equal(test.expOutcome, res.Outcome)
+				assert.Equal("/change/1", res.ChangeID)
+				assert.Equal("batman.dc.superheroes.comics.", res.ZoneID)
+				assert.Equal(model.ActionCreate, res.Action)
+				assert.Equal([]model.TXT{{Name: expTXTs[0].Name, Value: expTXTs[0].Value}}, res.TXTs)
+				mv.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestAdopterAdoptVerifyAlreadyOwned(t *testing.T) {
+	tests := []struct {
+		name       string
+		verifyErr  error
+		expOutcome model.Outcome
+	}{
+		{
+			name:       "A verified already owned host should be already owned.",
+			expOutcome: model.OutcomeAlreadyOwned,
+		},
+		{
+			name:       "A not verified already owned host (unverified on a previous run) should be unverified.",
+			verifyErr:  errors.New("wanted error"),
+			expOutcome: model.OutcomeUnverified,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockDefaultListResourceRecordSetsRequest())
+			mv := &mverify.Verifier{}
+			expTXTs := []verify.TXT{{Name: "owned.batman.dc.superheroes.comics", Value: `"heritage=external-dns,external-dns/owner=default"`}}
+			mv.On("Verify", mock.Anything, "batman.dc.superheroes.comics.", "", expTXTs).Once().Return(test.verifyErr)
+
+			ad := adopt.NewRSAdopter(adopt.Config{Verifier: mv}, mr53, log.Dummy)
+			res, err := ad.Adopt(context.Background(), &model.Entry{
+				Host: "owned.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			})
+
+			// Verified over DNS without a change to wait for.
+			if assert.NoError(err) {
+				assert.Equal(test.expOutcome, res.Outcome)
+				mv.AssertExpectations(t)
+				mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestAdopterAdoptCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks.
+	mr53 := &mroute53iface.Route53API{}
+	mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+	mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockDefaultListResourceRecordSetsRequest())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ad := adopt.NewRSAdopter(adopt.Config{}, mr53, log.Dummy)
+	res, err := ad.Adopt(ctx, &model.Entry{
+		Host: "valid.without.txt.batman.dc.superheroes.comics",
+		TXT:  "heritage=external-dns,external-dns/owner=default",
+	})
+
+	// The change is not sent once the context is done.
+	if assert.Error(err) {
+		assert.Equal(model.OutcomeError, res.Outcome)
+		mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+	}
+}
+
+func TestAdopterAdoptListsHostRecordSets(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks.
+	mr53 := &mroute53iface.Route53API{}
+	mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+	gotNames := []string{}
+	mr53.On("ListResourceRecordSetsRequest", mock.Anything).Run(func(args mock.Arguments) {
+		input := args.Get(0).(*route53.ListResourceRecordSetsInput)
+		gotNames = append(gotNames, aws.StringValue(input.StartRecordName))
+	}).Return(mockDefaultListResourceRecordSetsRequest())
+	mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Return(mockChangeResourceRecordSetsRequest(nil))
+
+	cfg := adopt.Config{Naming: registry.Naming{Prefix: "%{record_type}-"}}
+	ad := adopt.NewRSAdopter(cfg, mr53, log.Dummy)
+	_, err := ad.Adopt(context.Background(), &model.Entry{
+		Host: "valid.without.txt.batman.dc.superheroes.comics",
+		TXT:  "heritage=external-dns,external-dns/owner=default",
+	})
+
+	// Only the names of the host and its ownership TXT records are listed, not the whole zone.
+	if assert.NoError(err) {
+		assert.Equal([]string{
+			"valid.without.txt.batman.dc.superheroes.comics.",
+			"a-valid.without.txt.batman.dc.superheroes.comics.",
+			"aaaa-valid.without.txt.batman.dc.superheroes.comics.",
+			"cname-valid.without.txt.batman.dc.superheroes.comics.",
+		}, gotNames)
+	}
+}
+
+type ctxKey string
+
+func TestAdopterAdoptRequestsContext(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks.
+	mr53 := &mroute53iface.Route53API{}
+	hzReq := mockDefaultListHostedZones()
+	rrsReq := mockDefaultListResourceRecordSetsRequest()
+	chReq := mockChangeResourceRecordSetsRequest(nil)
+	mr53.On("ListHostedZonesRequest", mock.Anything).Return(hzReq)
+	mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(rrsReq)
+	mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Return(chReq)
+
+	ctx := context.WithValue(context.Background(), ctxKey("hero"), "batman")
+	ad := adopt.NewRSAdopter(adopt.Config{}, mr53, log.Dummy)
+	_, err := ad.Adopt(ctx, &model.Entry{
+		Host: "valid.without.txt.batman.dc.superheroes.comics",
+		TXT:  "heritage=external-dns,external-dns/owner=default",
+	})
+
+	// All the Route53 requests are sent with the context of the adoption.
+	if assert.NoError(err) {
+		assert.Equal("batman", hzReq.Context().Value(ctxKey("hero")))
+		assert.Equal("batman", rrsReq.Context().Value(ctxKey("hero")))
+		assert.Equal("batman", chReq.Context().Value(ctxKey("hero")))
+	}
+}
+
+func TestAdopterAdoptZoneFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks.
+	mr53 := &mroute53iface.Route53API{}
+	mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+
+	cfg := adopt.Config{ZoneFilter: regexp.MustCompile(`^marvel\.`)}
+	ad := adopt.NewRSAdopter(cfg, mr53, log.Dummy)
+	res, err := ad.Adopt(context.Background(), &model.Entry{
+		Host: "valid.without.txt.batman.dc.superheroes.comics",
+		TXT:  "heritage=external-dns,external-dns/owner=default",
+	})
+
+	// The hosts of the not selected zones are not touched.
+	if assert.NoError(err) {
+		assert.Equal(model.OutcomeSkipped, res.Outcome)
+		mr53.AssertNotCalled(t, "ListResourceRecordSetsRequest", mock.Anything)
+	}
+}
+
+func TestAdopterAdoptOwners(t *testing.T) {
+	tests := []struct {
+		name       string
+		assignment *owner.Assignment
+		assignErr  error
+		expOutcome model.Outcome
+		expTXTs    []model.TXT
+		expRule    string
+	}{
+		{
+			name:       "A host with an assigned owner ID should be adopted with the owner ID of the rule.",
+			assignment: &owner.Assignment{OwnerID: "robin", Rule: "sidekicks"},
+			expOutcome: model.OutcomeAdopted,
+			expTXTs:    []model.TXT{{Name: "valid.without.txt.batman.dc.superheroes.comics", Value: `"heritage=external-dns,external-dns/owner=robin"`}},
+			expRule:    "sidekicks",
+		},
+		{
+			name:       "A host without owner ID should be skipped.",
+			assignErr:  owner.ErrNoRule,
+			expOutcome: model.OutcomeSkipped,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockDefaultListHostedZones())
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockDefaultListResourceRecordSetsRequest())
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Return(mockChangeResourceRecordSetsRequest(&route53.ChangeResourceRecordSetsOutput{
+				ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/1"), Status: route53.ChangeStatusPending},
+			}))
+			mo := &mowner.Assigner{}
+			mo.On("Assign", "valid.without.txt.batman.dc.superheroes.comics", "batman.dc.superheroes.comics", mock.Anything).Once().Return(test.assignment, test.assignErr)
+
+			ad := adopt.NewRSAdopter(adopt.Config{Owners: mo}, mr53, log.Dummy)
+			entry := &model.Entry{
+				Host: "valid.without.txt.batman.dc.superheroes.comics",
+				TXT:  "heritage=external-dns,external-dns/owner=default",
+			}
+			res, err := ad.Adopt(context.Background(), entry)
+			if assert.NoError(err) {
+				assert.Equal(test.expOutcome, res.Outcome)
+				assert.Equal(test.expTXTs, res.TXTs)
+				assert.Equal(test.expRule, res.Rule)
+				assert.Equal("heritage=external-dns,external-dns/owner=default", entry.TXT)
+				mo.AssertExpectations(t)
+			}
+		})
+	}
 }
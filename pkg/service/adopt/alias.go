@@ -0,0 +1,149 @@
+package adopt
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+)
+
+const dualstackPrefix = "dualstack."
+
+// canonicalHostedZones are the hosted zones of the AWS load balancers by DNS name suffix,
+// the same ones external-dns uses to create alias records.
+var canonicalHostedZones = map[string]string{
+	// Classic and application load balancers.
+	"us-east-2.elb.amazonaws.com":         "Z3AADJGX6KTTL2",
+	"us-east-1.elb.amazonaws.com":         "Z35SXDOTRQ7X7K",
+	"us-west-1.elb.amazonaws.com":         "Z368ELLRRE2KJ0",
+	"us-west-2.elb.amazonaws.com":         "Z1H1FL5HABSF5",
+	"ca-central-1.elb.amazonaws.com":      "ZQSVJUPU6J1EY",
+	"ap-south-1.elb.amazonaws.com":        "ZP97RAFLXTNZK",
+	"ap-northeast-2.elb.amazonaws.com":    "ZWKZPGTI48KDX",
+	"ap-southeast-1.elb.amazonaws.com":    "Z1LMS91P8CMLE5",
+	"ap-southeast-2.elb.amazonaws.com":    "Z1GM3OXH4ZPM65",
+	"ap-northeast-1.elb.amazonaws.com":    "Z14GRHDCWA56QT",
+	"eu-central-1.elb.amazonaws.com":      "Z215JYRZR1TBD5",
+	"eu-west-1.elb.amazonaws.com":         "Z32O12XQLNTSW2",
+	"eu-west-2.elb.amazonaws.com":         "ZHURV8PSTC4K8",
+	"eu-west-3.elb.amazonaws.com":         "Z3Q77PNBQS71R4",
+	"sa-east-1.elb.amazonaws.com":         "Z2P70J7HTTTPLU",
+	"cn-north-1.elb.amazonaws.com.cn":     "Z1GDH35T77C1KE",
+	"cn-northwest-1.elb.amazonaws.com.cn": "ZM7IZAIOVVDZF",
+	// Network load balancers.
+	"elb.us-east-2.amazonaws.com":         "ZLMOA37VPKANP",
+	"elb.us-east-1.amazonaws.com":         "Z26RNL4JYFTOTI",
+	"elb.us-west-1.amazonaws.com":         "Z24FKFUX50B4VW",
+	"elb.us-west-2.amazonaws.com":         "Z18D5FSROUN65G",
+	"elb.ca-central-1.amazonaws.com":      "Z2EPGBW3API2WT",
+	"elb.ap-south-1.amazonaws.com":        "ZVDDRBQ08TROA",
+	"elb.ap-northeast-2.amazonaws.com":    "ZIBE1TIR4HY56",
+	"elb.ap-southeast-1.amazonaws.com":    "ZKVM4W9LS7TM",
+	"elb.ap-southeast-2.amazonaws.com":    "ZCT6FZBF4DROD",
+	"elb.ap-northeast-1.amazonaws.com":    "Z31USIVHYNEOWT",
+	"elb.eu-central-1.amazonaws.com":      "Z3F0SRJ5LGBH90",
+	"elb.eu-west-1.amazonaws.com":         "Z2IFOLAFXWLO4F",
+	"elb.eu-west-2.amazonaws.com":         "ZD4D7Y8KGAS4G",
+	"elb.eu-west-3.amazonaws.com":         "Z1CMS0P5QUZ6D5",
+	"elb.sa-east-1.amazonaws.com":         "ZTK26PT1VY4CU",
+	"elb.cn-north-1.amazonaws.com.cn":     "Z3QFB96KMJ7ED6",
+	"elb.cn-northwest-1.amazonaws.com.cn": "ZQEIKTCZ8352D",
+}
+
+// canonicalHostedZone returns the hosted zone of the load balancer target, empty
+// if the target is not an AWS load balancer.
+func canonicalHostedZone(target string) string {
+	target = strings.ToLower(strings.TrimSuffix(target, "."))
+	for suffix, zone := range canonicalHostedZones {
+		if strings.HasSuffix(target, "."+suffix) {
+			return zone
+		}
+	}
+
+	return ""
+}
+
+// cnameTarget returns the target of a CNAME record set.
+func cnameTarget(rs route53.ResourceRecordSet) string {
+	if rs.Type != route53.RRTypeCname || len(rs.ResourceRecords) == 0 {
+		return ""
+	}
+	return aws.StringValue(rs.ResourceRecords[0].Value)
+}
+
+// reportAliases logs the alias record sets and warns when the shape of the record sets
+// differ from the ones that external-dns will produce, so it would recreate them.
+func (a *adopter) reportAliases(rrs []route53.ResourceRecordSet, logger log.Logger) {
+	for _, rs := range rrs {
+		switch {
+		case rs.AliasTarget != nil:
+			target := aws.StringValue(rs.AliasTarget.DNSName)
+			logger.Infof("%s alias record set to %s (evaluate target health: %t)", rs.Type, target, aws.BoolValue(rs.AliasTarget.EvaluateTargetHealth))
+
+			if canonicalHostedZone(target) == "" {
+				logger.Warnf("%s alias record set target is not a load balancer, external-dns will produce a CNAME unless the ingress has the alias annotation", rs.Type)
+			} else if a.cfg.PreferCNAME {
+				logger.Warnf("%s alias record set will be replaced by a CNAME by external-dns using prefer CNAME", rs.Type)
+			}
+		case rs.Type == route53.RRTypeCname:
+			if canonicalHostedZone(cnameTarget(rs)) != "" && !a.cfg.PreferCNAME && !a.cfg.ConvertCNAMEToAlias {
+				logger.Warnf("CNAME record set to a load balancer will be replaced by an alias record by external-dns, this will cause a brief outage")
+			}
+		}
+	}
+}
+
+// cnameToAliasChanges returns the changes to replace the CNAME record sets that point to
+// AWS load balancers with alias records. Dualstack load balancers also get an AAAA alias.
+func (a *adopter) cnameToAliasChanges(rrs []route53.ResourceRecordSet, logger log.Logger) []route53.Change {
+	changes := []route53.Change{}
+	for _, rs := range rrs {
+		if rs.Type != route53.RRTypeCname {
+			continue
+		}
+
+		target := cnameTarget(rs)
+		zone := canonicalHostedZone(target)
+		if zone == "" {
+			logger.Warnf("CNAME record set to %s can't be converted to alias, target is not a load balancer", target)
+			continue
+		}
+
+		rs := rs
+		changes = append(changes, route53.Change{
+			Action:            route53.ChangeActionDelete,
+			ResourceRecordSet: &rs,
+		})
+
+		types := []route53.RRType{route53.RRTypeA}
+		if strings.HasPrefix(strings.ToLower(target), dualstackPrefix) {
+			types = append(types, route53.RRTypeAaaa)
+		}
+		for _, t := range types {
+			changes = append(changes, route53.Change{
+				Action: route53.ChangeActionCreate,
+				ResourceRecordSet: &route53.ResourceRecordSet{
+					Name: rs.Name,
+					Type: t,
+					AliasTarget: &route53.AliasTarget{
+						DNSName:      aws.String(target),
+						HostedZoneId: aws.String(zone),
+						// Same as external-dns default.
+						EvaluateTargetHealth: aws.Bool(true),
+					},
+					SetIdentifier: rs.SetIdentifier,
+					Weight:        rs.Weight,
+					Region:        rs.Region,
+					Failover:      rs.Failover,
+					GeoLocation:   rs.GeoLocation,
+					HealthCheckId: rs.HealthCheckId,
+				},
+			})
+		}
+		logger.Infof("CNAME record set to %s will be converted to %d alias record sets", target, len(types))
+	}
+
+	return changes
+}
@@ -0,0 +1,47 @@
+package adopt
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+)
+
+const (
+	// MaxBatchChanges is the maximum number of changes sent on a single change batch.
+	MaxBatchChanges = 100
+)
+
+// SendChanges sends the changes of a hosted zone to Route53 splitting them in change
+// batches of MaxBatchChanges, returns the IDs of the sent change batches.
+func SendChanges(ctx context.Context, r53Svc route53iface.Route53API, hzID, comment string, changes []route53.Change) ([]string, error) {
+	ids := []string{}
+	for len(changes) > 0 {
+		n := MaxBatchChanges
+		if len(changes) < n {
+			n = len(changes)
+		}
+
+		input := &route53.ChangeResourceRecordSetsInput{
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: changes[:n],
+				Comment: aws.String(comment),
+			},
+			HostedZoneId: aws.String(hzID),
+		}
+		req := r53Svc.ChangeResourceRecordSetsRequest(input)
+		req.SetContext(ctx)
+		resp, err := req.Send()
+		if err != nil {
+			return ids, err
+		}
+		if resp != nil && resp.ChangeInfo != nil {
+			ids = append(ids, aws.StringValue(resp.ChangeInfo.Id))
+		}
+
+		changes = changes[n:]
+	}
+
+	return ids, nil
+}
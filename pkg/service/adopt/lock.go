@@ -0,0 +1,27 @@
+package adopt
+
+import "sync"
+
+// zoneLocks are the locks of the hosted zones.
+type zoneLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newZoneLocks() *zoneLocks {
+	return &zoneLocks{locks: map[string]*sync.Mutex{}}
+}
+
+// lock locks the hosted zone and returns the function that unlocks it.
+func (z *zoneLocks) lock(hzID string) func() {
+	z.mu.Lock()
+	l, ok := z.locks[hzID]
+	if !ok {
+		l = &sync.Mutex{}
+		z.locks[hzID] = l
+	}
+	z.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
@@ -0,0 +1,46 @@
+package adopt
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// ChangeSet are the Route53 changes required to adopt a host.
+type ChangeSet struct {
+	ZoneID string
+	Zone   string
+	Host   string
+	// Owner is the owner ID of the ownership TXT records, Rule the owner rule that assigned it (if any).
+	Owner string
+	Rule  string
+	// Changes need to be applied in the same change batch.
+	Changes []route53.Change
+	// Current are the record sets (of the host and the ownership records) that the changes are based on.
+	Current []route53.ResourceRecordSet
+}
+
+// ChangeRecorder knows how to record the changes of the adoptions so they can be applied later.
+type ChangeRecorder interface {
+	Record(cs *ChangeSet)
+}
+
+// currentRecordSets returns the record sets of the zone that have the names of the host and the
+// record sets of the changes.
+func currentRecordSets(zonerrs []route53.ResourceRecordSet, host string, changes []route53.Change) []route53.ResourceRecordSet {
+	names := []string{host}
+	for _, ch := range changes {
+		names = append(names, *ch.ResourceRecordSet.Name)
+	}
+
+	res := []route53.ResourceRecordSet{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		rrs := recordSetsNamed(zonerrs, name)
+		if len(rrs) == 0 || seen[*rrs[0].Name] {
+			continue
+		}
+		seen[*rrs[0].Name] = true
+		res = append(res, rrs...)
+	}
+
+	return res
+}
@@ -0,0 +1,197 @@
+package adopt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+)
+
+// ErrNoHostedZone is returned when there isn't a hosted zone for a host.
+var ErrNoHostedZone = errors.New("no hosted zones available")
+
+// ZoneIndex knows how to find the hosted zone where a host belongs.
+type ZoneIndex interface {
+	// Find returns the most specific hosted zone for the host.
+	Find(ctx context.Context, host string) (*route53.HostedZone, error)
+	// Zones returns all the hosted zones of the index.
+	Zones(ctx context.Context) ([]route53.HostedZone, error)
+}
+
+type zoneIndex struct {
+	r53Svc route53iface.Route53API
+
+	mu    sync.Mutex
+	zones map[string]route53.HostedZone
+}
+
+// NewZoneIndex returns a new ZoneIndex, the hosted zones will be loaded
+// from Route53 the first time they are needed.
+func NewZoneIndex(r53Svc route53iface.Route53API) ZoneIndex {
+	return &zoneIndex{
+		r53Svc: r53Svc,
+	}
+}
+
+func (z *zoneIndex) Find(ctx context.Context, host string) (*route53.HostedZone, error) {
+	zones, err := z.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sanitize domain and get the different subdomain levels.
+	host = strings.TrimSuffix(host, ".")
+	splDomain := strings.Split(host, ".")[1:] // We get rid of the first one (wildcard or direct one)
+
+	// Get the correct hosted zone. On each iteration it will remove a subdomain level
+	// until it finds the zone.
+	for i := 0; i < len(splDomain)-1; i++ {
+		// Generate domain.
+		domain := strings.Join(splDomain[i:], ".")
+
+		// If HZ found then finish.
+		if zone, ok := zones[domain]; ok {
+			return &zone, nil
+		}
+	}
+
+	return nil, ErrNoHostedZone
+}
+
+func (z *zoneIndex) Zones(ctx context.Context) ([]route53.HostedZone, error) {
+	zones, err := z.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []route53.HostedZone{}
+	for _, zone := range zones {
+		res = append(res, zone)
+	}
+	return res, nil
+}
+
+// load gets all the hosted zones from Route53 only once.
+func (z *zoneIndex) load(ctx context.Context) (map[string]route53.HostedZone, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.zones != nil {
+		return z.zones, nil
+	}
+
+	zones := map[string]route53.HostedZone{}
+	params := &route53.ListHostedZonesInput{}
+	for {
+		req := z.r53Svc.ListHostedZonesRequest(params)
+		req.SetContext(ctx)
+		res, err := req.Send()
+		if err != nil {
+			return nil, err
+		}
+		for _, zone := range res.HostedZones {
+			name := strings.TrimSuffix(aws.StringValue(zone.Name), ".")
+			zones[name] = zone
+		}
+
+		// No more? then exit loop.
+		if !aws.BoolValue(res.IsTruncated) {
+			break
+		}
+		params.Marker = res.NextMarker
+	}
+
+	z.zones = zones
+	return zones, nil
+}
+
+// RecordSetGetter knows how to get the resource record sets of a hosted zone.
+type RecordSetGetter interface {
+	// GetRecordSets returns the record sets of the hosted zone with any of the names, only
+	// the record sets of the names are listed.
+	GetRecordSets(ctx context.Context, hzID string, names ...string) ([]route53.ResourceRecordSet, error)
+	// ListRecordSets returns all the record sets of the hosted zone.
+	ListRecordSets(ctx context.Context, hzID string) ([]route53.ResourceRecordSet, error)
+}
+
+type recordSetGetter struct {
+	r53Svc route53iface.Route53API
+}
+
+// NewRecordSetGetter returns a new RecordSetGetter.
+func NewRecordSetGetter(r53Svc route53iface.Route53API) RecordSetGetter {
+	return &recordSetGetter{
+		r53Svc: r53Svc,
+	}
+}
+
+func (r *recordSetGetter) GetRecordSets(ctx context.Context, hzID string, names ...string) ([]route53.ResourceRecordSet, error) {
+	rrs := []route53.ResourceRecordSet{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimRight(name, ".") + "." // Set always the dot at the end.
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		// The record sets are sorted by name, so the listing starts on the name and
+		// stops when the next record set is of another name.
+		params := &route53.ListResourceRecordSetsInput{
+			HostedZoneId:    aws.String(hzID),
+			StartRecordName: aws.String(name),
+		}
+		for {
+			req := r.r53Svc.ListResourceRecordSetsRequest(params)
+			req.SetContext(ctx)
+			resp, err := req.Send()
+			if err != nil {
+				return nil, err
+			}
+			rrs = append(rrs, recordSetsNamed(resp.ResourceRecordSets, name)...)
+
+			if !aws.BoolValue(resp.IsTruncated) || aws.StringValue(resp.NextRecordName) != name {
+				break
+			}
+			params.StartRecordType = resp.NextRecordType
+			params.StartRecordIdentifier = resp.NextRecordIdentifier
+		}
+	}
+
+	return rrs, nil
+}
+
+func (r *recordSetGetter) ListRecordSets(ctx context.Context, hzID string) ([]route53.ResourceRecordSet, error) {
+	rrs := []route53.ResourceRecordSet{}
+
+	params := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hzID),
+	}
+	for {
+		req := r.r53Svc.ListResourceRecordSetsRequest(params)
+		req.SetContext(ctx)
+		resp, err := req.Send()
+		if err != nil {
+			return nil, err
+		}
+
+		// Save current records.
+		rrs = append(rrs, resp.ResourceRecordSets...)
+
+		// No more? then exit loop.
+		if !aws.BoolValue(resp.IsTruncated) {
+			break
+		}
+
+		// prepare the call to grab the next ones.
+		params.StartRecordName = resp.NextRecordName
+		params.StartRecordType = resp.NextRecordType
+		params.StartRecordIdentifier = resp.NextRecordIdentifier
+	}
+
+	return rrs, nil
+}
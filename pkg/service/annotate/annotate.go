@@ -0,0 +1,197 @@
+package annotate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+const (
+	// defTTL is the TTL that external-dns sets when the ttl annotation is missing.
+	defTTL = 300
+)
+
+// Patch are the annotations that need to be set on an ingress so external-dns
+// keeps the record sets of its hosts as they are.
+type Patch struct {
+	Namespace   string
+	Name        string
+	Hosts       []string
+	Annotations map[string]string
+	// Unsupported are the attributes of the record sets that external-dns can't express.
+	Unsupported []string
+}
+
+// Annotator knows how to get the external-dns annotations that preserve the
+// current attributes of the adopted record sets.
+type Annotator interface {
+	// Annotate registers the record sets of an adopted entry.
+	Annotate(entry *model.Entry, rrs []route53.ResourceRecordSet)
+	// Patches returns the patches of all the annotated ingresses.
+	Patches() []*Patch
+}
+
+type annotator struct {
+	mu      sync.Mutex
+	patches map[string]*Patch
+	logger  log.Logger
+}
+
+// NewAnnotator returns a new Annotator.
+func NewAnnotator(logger log.Logger) Annotator {
+	return &annotator{
+		patches: map[string]*Patch{},
+		logger:  logger,
+	}
+}
+
+func (a *annotator) Annotate(entry *model.Entry, rrs []route53.ResourceRecordSet) {
+	logger := a.logger.With("host", entry.Host)
+	if entry.Ingress == "" {
+		logger.Warnf("host without ingress, can't generate the annotations")
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	patch, ok := a.patches[entry.Ingress]
+	if !ok {
+		ns, name := splitIngress(entry.Ingress)
+		patch = &Patch{
+			Namespace:   ns,
+			Name:        name,
+			Annotations: map[string]string{},
+		}
+		a.patches[entry.Ingress] = patch
+	}
+	patch.Hosts = append(patch.Hosts, entry.Host)
+
+	annotations, unsupported := hostAnnotations(rrs)
+	for _, u := range unsupported {
+		patch.Unsupported = append(patch.Unsupported, fmt.Sprintf("%s: %s", entry.Host, u))
+	}
+
+	// All the hosts of an ingress share the annotations.
+	for k, v := range annotations {
+		if cv, ok := patch.Annotations[k]; ok && cv != v {
+			patch.Unsupported = append(patch.Unsupported, fmt.Sprintf("%s: requires %s=%s but other host of the ingress requires %s", entry.Host, k, v, cv))
+			continue
+		}
+		patch.Annotations[k] = v
+	}
+
+	if len(unsupported) > 0 {
+		logger.Warnf("record sets with attributes that external-dns can't express: %s", strings.Join(unsupported, ", "))
+	}
+}
+
+func (a *annotator) Patches() []*Patch {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	patches := []*Patch{}
+	for _, p := range a.patches {
+		patches = append(patches, p)
+	}
+	sort.Slice(patches, func(i, j int) bool {
+		if patches[i].Namespace != patches[j].Namespace {
+			return patches[i].Namespace < patches[j].Namespace
+		}
+		return patches[i].Name < patches[j].Name
+	})
+
+	return patches
+}
+
+// hostAnnotations returns the annotations required for the record sets of a
+// host and the attributes that can't be expressed with annotations.
+func hostAnnotations(rrs []route53.ResourceRecordSet) (map[string]string, []string) {
+	annotations := map[string]string{}
+	unsupported := []string{}
+
+	// Only the record sets that external-dns manages.
+	hrrs := []route53.ResourceRecordSet{}
+	setIDs := map[string]bool{}
+	for _, rs := range rrs {
+		switch rs.Type {
+		case route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname:
+			hrrs = append(hrrs, rs)
+			if rs.SetIdentifier != nil {
+				setIDs[aws.StringValue(rs.SetIdentifier)] = true
+			}
+		}
+	}
+	if len(setIDs) > 1 {
+		unsupported = append(unsupported, fmt.Sprintf("%d set identifiers on the same host, an ingress only can have one", len(setIDs)))
+		return annotations, unsupported
+	}
+
+	for _, rs := range hrrs {
+		set := func(k, v string) {
+			if cv, ok := annotations[k]; ok && cv != v {
+				unsupported = append(unsupported, fmt.Sprintf("%s record sets with different %s values (%s and %s)", rs.Type, k, cv, v))
+				return
+			}
+			annotations[k] = v
+		}
+
+		if rs.AliasTarget != nil {
+			set(model.AliasAnnotation, "true")
+		} else if ttl := aws.Int64Value(rs.TTL); ttl != defTTL {
+			set(model.TTLAnnotation, strconv.FormatInt(ttl, 10))
+		}
+
+		if rs.SetIdentifier != nil {
+			set(model.SetIdentifierAnnotation, aws.StringValue(rs.SetIdentifier))
+		}
+		if rs.Weight != nil {
+			set(model.AWSWeightAnnotation, strconv.FormatInt(aws.Int64Value(rs.Weight), 10))
+		}
+		if rs.Region != "" {
+			set(model.AWSRegionAnnotation, string(rs.Region))
+		}
+		if rs.Failover != "" {
+			set(model.AWSFailoverAnnotation, string(rs.Failover))
+		}
+		if gl := rs.GeoLocation; gl != nil {
+			if gl.ContinentCode != nil {
+				set(model.AWSGeoContinentCodeAnnotation, aws.StringValue(gl.ContinentCode))
+			}
+			if gl.CountryCode != nil {
+				set(model.AWSGeoCountryCodeAnnotation, aws.StringValue(gl.CountryCode))
+			}
+			if gl.SubdivisionCode != nil {
+				set(model.AWSGeoSubdivisionCodeAnnotation, aws.StringValue(gl.SubdivisionCode))
+			}
+		}
+		if rs.HealthCheckId != nil {
+			set(model.AWSHealthCheckIDAnnotation, aws.StringValue(rs.HealthCheckId))
+		}
+
+		if aws.BoolValue(rs.MultiValueAnswer) {
+			unsupported = append(unsupported, fmt.Sprintf("%s record set with multivalue answer routing policy", rs.Type))
+		}
+		if rs.TrafficPolicyInstanceId != nil {
+			unsupported = append(unsupported, fmt.Sprintf("%s record set managed by traffic policy %s", rs.Type, aws.StringValue(rs.TrafficPolicyInstanceId)))
+		}
+	}
+
+	return annotations, unsupported
+}
+
+func splitIngress(ingress string) (namespace, name string) {
+	parts := strings.SplitN(ingress, "/", 2)
+	if len(parts) == 1 {
+		return "default", parts[0]
+	}
+	return parts[0], parts[1]
+}
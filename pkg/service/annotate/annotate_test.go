@@ -0,0 +1,123 @@
+package annotate_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/annotate"
+)
+
+func TestAnnotatorPatches(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    []*model.Entry
+		rrs        [][]route53.ResourceRecordSet
+		expPatches []*annotate.Patch
+	}{
+		{
+			name: "Record sets with default attributes should not need annotations.",
+			entries: []*model.Entry{
+				{Host: "batman.gotham.dc.comics", Ingress: "heroes/batman"},
+			},
+			rrs: [][]route53.ResourceRecordSet{
+				{
+					{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(300)},
+				},
+			},
+			expPatches: []*annotate.Patch{
+				{
+					Namespace:   "heroes",
+					Name:        "batman",
+					Hosts:       []string{"batman.gotham.dc.comics"},
+					Annotations: map[string]string{},
+				},
+			},
+		},
+		{
+			name: "Weighted record sets should get routing policy and TTL annotations.",
+			entries: []*model.Entry{
+				{Host: "batman.gotham.dc.comics", Ingress: "heroes/batman"},
+				{Host: "robin.gotham.dc.comics", Ingress: "heroes/batman"},
+			},
+			rrs: [][]route53.ResourceRecordSet{
+				{
+					{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeCname, TTL: aws.Int64(60), SetIdentifier: aws.String("blue"), Weight: aws.Int64(10)},
+				},
+				{
+					{Name: aws.String("robin.gotham.dc.comics."), Type: route53.RRTypeCname, TTL: aws.Int64(60), SetIdentifier: aws.String("blue"), Weight: aws.Int64(10)},
+				},
+			},
+			expPatches: []*annotate.Patch{
+				{
+					Namespace: "heroes",
+					Name:      "batman",
+					Hosts:     []string{"batman.gotham.dc.comics", "robin.gotham.dc.comics"},
+					Annotations: map[string]string{
+						model.TTLAnnotation:           "60",
+						model.SetIdentifierAnnotation: "blue",
+						model.AWSWeightAnnotation:     "10",
+					},
+				},
+			},
+		},
+		{
+			name: "Hosts of the same ingress with different attributes and multivalue record sets should be flagged.",
+			entries: []*model.Entry{
+				{Host: "batman.gotham.dc.comics", Ingress: "heroes/batman"},
+				{Host: "robin.gotham.dc.comics", Ingress: "heroes/batman"},
+			},
+			rrs: [][]route53.ResourceRecordSet{
+				{
+					{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60)},
+				},
+				{
+					{Name: aws.String("robin.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(30), SetIdentifier: aws.String("a"), MultiValueAnswer: aws.Bool(true)},
+				},
+			},
+			expPatches: []*annotate.Patch{
+				{
+					Namespace: "heroes",
+					Name:      "batman",
+					Hosts:     []string{"batman.gotham.dc.comics", "robin.gotham.dc.comics"},
+					Annotations: map[string]string{
+						model.TTLAnnotation:           "60",
+						model.SetIdentifierAnnotation: "a",
+					},
+					Unsupported: []string{
+						"robin.gotham.dc.comics: A record set with multivalue answer routing policy",
+						"robin.gotham.dc.comics: requires external-dns.alpha.kubernetes.io/ttl=30 but other host of the ingress requires 60",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			an := annotate.NewAnnotator(log.Dummy)
+			for i, e := range test.entries {
+				an.Annotate(e, test.rrs[i])
+			}
+
+			patches := an.Patches()
+			assert.Equal(test.expPatches, patches)
+
+			// The patches should be writable in all the formats.
+			for _, f := range []annotate.Format{annotate.KubectlFormat, annotate.KustomizeFormat} {
+				var b bytes.Buffer
+				require.NoError(annotate.WritePatches(&b, f, patches))
+				assert.Contains(b.String(), "batman")
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package annotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Format is the format of the patches.
+type Format string
+
+// Formats.
+const (
+	// KubectlFormat writes the patches as kubectl patch commands.
+	KubectlFormat Format = "kubectl"
+	// KustomizeFormat writes the patches as kustomize strategic merge patches.
+	KustomizeFormat Format = "kustomize"
+)
+
+// WritePatches writes the patches in the required format. The unsupported
+// attributes are written as comments so they can be reviewed.
+func WritePatches(w io.Writer, format Format, patches []*Patch) error {
+	switch format {
+	case KubectlFormat:
+		return writeKubectlPatches(w, patches)
+	case KustomizeFormat:
+		return writeKustomizePatches(w, patches)
+	}
+
+	return fmt.Errorf("invalid patch format %q", format)
+}
+
+func writeKubectlPatches(w io.Writer, patches []*Patch) error {
+	for _, p := range patches {
+		writeComments(w, p)
+		if len(p.Annotations) == 0 {
+			fmt.Fprintf(w, "# %s/%s doesn't need annotations\n\n", p.Namespace, p.Name)
+			continue
+		}
+
+		body := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": p.Annotations,
+			},
+		}
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "kubectl --namespace %s patch ingress %s --type merge --patch '%s'\n\n", p.Namespace, p.Name, bs)
+	}
+
+	return nil
+}
+
+func writeKustomizePatches(w io.Writer, patches []*Patch) error {
+	for i, p := range patches {
+		if i > 0 {
+			fmt.Fprintf(w, "---\n")
+		}
+		writeComments(w, p)
+		fmt.Fprintf(w, "apiVersion: extensions/v1beta1\n")
+		fmt.Fprintf(w, "kind: Ingress\n")
+		fmt.Fprintf(w, "metadata:\n")
+		fmt.Fprintf(w, "  name: %s\n", p.Name)
+		fmt.Fprintf(w, "  namespace: %s\n", p.Namespace)
+		if len(p.Annotations) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  annotations:\n")
+		for _, k := range sortedKeys(p.Annotations) {
+			fmt.Fprintf(w, "    %s: %q\n", k, p.Annotations[k])
+		}
+	}
+
+	return nil
+}
+
+func writeComments(w io.Writer, p *Patch) {
+	fmt.Fprintf(w, "# Hosts: %s\n", strings.Join(p.Hosts, ", "))
+	for _, u := range p.Unsupported {
+		fmt.Fprintf(w, "# UNSUPPORTED: %s\n", u)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+)
+
+// Class is the ownership classification of a record.
+type Class string
+
+// Classes.
+const (
+	// ClassOwned are the records owned by the audited owner ID.
+	ClassOwned Class = "owned"
+	// ClassOtherOwner are the records owned by other external-dns owner ID.
+	ClassOtherOwner Class = "other-owner"
+	// ClassUnowned are the records not owned by any external-dns.
+	ClassUnowned Class = "unowned"
+	// ClassOrphan are the ownership TXT records without the record that they own.
+	ClassOrphan Class = "orphan"
+)
+
+// Classes are all the classes in order.
+var Classes = []Class{ClassOwned, ClassOtherOwner, ClassUnowned, ClassOrphan}
+
+// Record is an audited record.
+type Record struct {
+	Zone          string `json:"zone"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	Class         Class  `json:"class"`
+	Owner         string `json:"owner,omitempty"`
+}
+
+// ZoneSummary are the counts of the audited records of a hosted zone.
+type ZoneSummary struct {
+	ID     string                   `json:"id"`
+	Name   string                   `json:"name"`
+	Counts map[Class]int            `json:"counts"`
+	Owners map[string]map[Class]int `json:"owners"`
+}
+
+// Report is the result of the audit.
+type Report struct {
+	OwnerID string         `json:"ownerID"`
+	Zones   []*ZoneSummary `json:"zones"`
+	Records []Record       `json:"records"`
+}
+
+// Config is the configuration of the auditor.
+type Config struct {
+	// OwnerID is the external-dns owner ID to audit.
+	OwnerID string
+	// ZoneFilter is the regex that selects the audited hosted zones by name.
+	ZoneFilter string
+	// Naming is the naming of the external-dns TXT registry records.
+	Naming registry.Naming
+}
+
+// Auditor knows how to audit the ownership of the records on the hosted zones.
+type Auditor interface {
+	Audit(ctx context.Context) (*Report, error)
+}
+
+type auditor struct {
+	cfg        Config
+	zoneFilter *regexp.Regexp
+	zones      adopt.ZoneIndex
+	rrsGetter  adopt.RecordSetGetter
+	logger     log.Logger
+}
+
+// NewAuditor returns a new Auditor.
+func NewAuditor(cfg Config, zones adopt.ZoneIndex, rrsGetter adopt.RecordSetGetter, logger log.Logger) (Auditor, error) {
+	r, err := regexp.Compile(cfg.ZoneFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auditor{
+		cfg:        cfg,
+		zoneFilter: r,
+		zones:      zones,
+		rrsGetter:  rrsGetter,
+		logger:     logger,
+	}, nil
+}
+
+func (a *auditor) Audit(ctx context.Context) (*Report, error) {
+	zones, err := a.zones.Zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return aws.StringValue(zones[i].Name) < aws.StringValue(zones[j].Name)
+	})
+
+	report := &Report{OwnerID: a.cfg.OwnerID, Records: []Record{}}
+	for _, hz := range zones {
+		name := strings.TrimSuffix(aws.StringValue(hz.Name), ".")
+		if !a.zoneFilter.MatchString(name) {
+			a.logger.Debugf("ignoring hosted zone %s", name)
+			continue
+		}
+
+		summary, records, err := a.auditZone(ctx, hz)
+		if err != nil {
+			return nil, err
+		}
+		report.Zones = append(report.Zones, summary)
+		report.Records = append(report.Records, records...)
+	}
+
+	return report, nil
+}
+
+func (a *auditor) auditZone(ctx context.Context, hz route53.HostedZone) (*ZoneSummary, []Record, error) {
+	summary := &ZoneSummary{
+		ID:     aws.StringValue(hz.Id),
+		Name:   strings.TrimSuffix(aws.StringValue(hz.Name), "."),
+		Counts: map[Class]int{},
+		Owners: map[string]map[Class]int{},
+	}
+
+	rrs, err := a.rrsGetter.ListRecordSets(ctx, summary.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ownership := registry.NewZoneOwnership(rrs, a.cfg.Naming)
+
+	records := []Record{}
+	add := func(rs route53.ResourceRecordSet, class Class, owner string) {
+		records = append(records, Record{
+			Zone:          summary.Name,
+			Name:          strings.TrimSuffix(aws.StringValue(rs.Name), "."),
+			Type:          string(rs.Type),
+			SetIdentifier: aws.StringValue(rs.SetIdentifier),
+			Class:         class,
+			Owner:         owner,
+		})
+		summary.Counts[class]++
+		if owner != "" {
+			if summary.Owners[owner] == nil {
+				summary.Owners[owner] = map[Class]int{}
+			}
+			summary.Owners[owner][class]++
+		}
+	}
+
+	for _, rs := range rrs {
+		switch rs.Type {
+		case route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname:
+		default:
+			continue
+		}
+
+		owner, ok := ownership.Owner(rs)
+		switch {
+		case !ok:
+			add(rs, ClassUnowned, "")
+		case owner == a.cfg.OwnerID:
+			add(rs, ClassOwned, owner)
+		default:
+			add(rs, ClassOtherOwner, owner)
+		}
+	}
+
+	for _, rs := range ownership.Orphans() {
+		owner, _ := registry.TXTOwner(rs)
+		add(rs, ClassOrphan, owner)
+	}
+
+	return summary, records, nil
+}
@@ -0,0 +1,131 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/audit"
+)
+
+func rrs(name string, t route53.RRType, values ...string) route53.ResourceRecordSet {
+	rs := route53.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: t,
+		TTL:  aws.Int64(300),
+	}
+	for _, v := range values {
+		rs.ResourceRecords = append(rs.ResourceRecords, route53.ResourceRecord{Value: aws.String(v)})
+	}
+	return rs
+}
+
+func TestAuditorAudit(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		zoneFilter string
+		rrs        []route53.ResourceRecordSet
+		expZones   int
+		expCounts  map[audit.Class]int
+		expOwners  map[string]map[audit.Class]int
+	}{
+		{
+			name:       "Auditing with legacy names should classify all the records.",
+			zoneFilter: `.*`,
+			rrs: []route53.ResourceRecordSet{
+				rrs("batman.gotham.dc.comics.", route53.RRTypeA, "10.0.0.1"),
+				rrs("batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+				rrs("joker.gotham.dc.comics.", route53.RRTypeCname, "arkham.dc.comics"),
+				rrs("joker.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=joker"`),
+				rrs("alfred.gotham.dc.comics.", route53.RRTypeA, "10.0.0.2"),
+				rrs("alfred.gotham.dc.comics.", route53.RRTypeTxt, `"v=spf1 -all"`),
+				rrs("robin.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+			},
+			expZones: 1,
+			expCounts: map[audit.Class]int{
+				audit.ClassOwned:      1,
+				audit.ClassOtherOwner: 1,
+				audit.ClassUnowned:    1,
+				audit.ClassOrphan:     1,
+			},
+			expOwners: map[string]map[audit.Class]int{
+				"batman": {audit.ClassOwned: 1, audit.ClassOrphan: 1},
+				"joker":  {audit.ClassOtherOwner: 1},
+			},
+		},
+		{
+			name:       "Auditing with type prefixed names should use the type of the records.",
+			prefix:     "%{record_type}-",
+			zoneFilter: `.*`,
+			rrs: []route53.ResourceRecordSet{
+				rrs("batman.gotham.dc.comics.", route53.RRTypeA, "10.0.0.1"),
+				rrs("a-batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+				rrs("cname-batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+			},
+			expZones: 1,
+			expCounts: map[audit.Class]int{
+				audit.ClassOwned:  1,
+				audit.ClassOrphan: 1,
+			},
+			expOwners: map[string]map[audit.Class]int{
+				"batman": {audit.ClassOwned: 1, audit.ClassOrphan: 1},
+			},
+		},
+		{
+			name:       "Auditing should ignore the not selected zones.",
+			zoneFilter: `^metropolis\..*`,
+			expZones:   0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(route53.ListHostedZonesRequest{
+				Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ListHostedZonesOutput{
+					HostedZones: []route53.HostedZone{{Name: aws.String("gotham.dc.comics."), Id: aws.String("gotham")}},
+				}},
+			})
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(route53.ListResourceRecordSetsRequest{
+				Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ListResourceRecordSetsOutput{ResourceRecordSets: test.rrs}},
+			})
+
+			au, err := audit.NewAuditor(audit.Config{
+				OwnerID:    "batman",
+				ZoneFilter: test.zoneFilter,
+				Naming:     registry.Naming{Prefix: test.prefix},
+			}, adopt.NewZoneIndex(mr53), adopt.NewRecordSetGetter(mr53), log.Dummy)
+			require.NoError(err)
+
+			report, err := au.Audit(context.Background())
+			require.NoError(err)
+			require.Len(report.Zones, test.expZones)
+			if test.expZones > 0 {
+				assert.Equal(test.expCounts, report.Zones[0].Counts)
+				assert.Equal(test.expOwners, report.Zones[0].Owners)
+			}
+
+			// The report should be writable in all the formats.
+			for _, f := range []audit.Format{audit.TableFormat, audit.JSONFormat, audit.CSVFormat} {
+				var b bytes.Buffer
+				assert.NoError(audit.WriteReport(&b, f, report))
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Format is the format of the audit report.
+type Format string
+
+// Formats.
+const (
+	TableFormat Format = "table"
+	JSONFormat  Format = "json"
+	CSVFormat   Format = "csv"
+)
+
+// WriteReport writes the audit report in the required format.
+func WriteReport(w io.Writer, format Format, report *Report) error {
+	switch format {
+	case TableFormat:
+		return writeTable(w, report)
+	case JSONFormat:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case CSVFormat:
+		return writeCSV(w, report)
+	}
+
+	return fmt.Errorf("invalid report format %q", format)
+}
+
+func writeTable(w io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	// Per zone.
+	fmt.Fprintf(tw, "ZONE\tID\tOWNED\tOTHER-OWNER\tUNOWNED\tORPHAN\n")
+	total := map[Class]int{}
+	for _, z := range report.Zones {
+		fmt.Fprintf(tw, "%s\t%s", z.Name, z.ID)
+		for _, c := range Classes {
+			fmt.Fprintf(tw, "\t%d", z.Counts[c])
+			total[c] += z.Counts[c]
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+	fmt.Fprintf(tw, "TOTAL\t")
+	for _, c := range Classes {
+		fmt.Fprintf(tw, "\t%d", total[c])
+	}
+	fmt.Fprintf(tw, "\n\n")
+
+	// Per owner.
+	fmt.Fprintf(tw, "OWNER\tRECORDS\tORPHAN\n")
+	owners := ownerCounts(report)
+	for _, owner := range sortedOwners(owners) {
+		counts := owners[owner]
+		mark := ""
+		if owner == report.OwnerID {
+			mark = " (audited)"
+		}
+		fmt.Fprintf(tw, "%s%s\t%d\t%d\n", owner, mark, counts[ClassOwned]+counts[ClassOtherOwner], counts[ClassOrphan])
+	}
+
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"zone", "owner", "class", "count"})
+
+	for _, z := range report.Zones {
+		for _, c := range Classes {
+			cw.Write([]string{z.Name, "", string(c), strconv.Itoa(z.Counts[c])})
+		}
+		for _, owner := range sortedOwners(z.Owners) {
+			for _, c := range Classes {
+				if n, ok := z.Owners[owner][c]; ok {
+					cw.Write([]string{z.Name, owner, string(c), strconv.Itoa(n)})
+				}
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ownerCounts returns the counts of all the zones by owner.
+func ownerCounts(report *Report) map[string]map[Class]int {
+	owners := map[string]map[Class]int{}
+	for _, z := range report.Zones {
+		for owner, counts := range z.Owners {
+			if owners[owner] == nil {
+				owners[owner] = map[Class]int{}
+			}
+			for c, n := range counts {
+				owners[owner][c] += n
+			}
+		}
+	}
+	return owners
+}
+
+func sortedOwners(owners map[string]map[Class]int) []string {
+	res := []string{}
+	for o := range owners {
+		res = append(res, o)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,132 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+)
+
+// Config is the configuration of the cleaner.
+type Config struct {
+	// DryRun will not make any change on Route53.
+	DryRun bool
+	// OwnerID if set, only the orphans of this owner ID will be cleaned.
+	OwnerID string
+	// ZoneFilter is the regex that selects the hosted zones by name.
+	ZoneFilter string
+	// Naming is the naming of the external-dns TXT registry records.
+	Naming registry.Naming
+}
+
+// Cleaner knows how to clean the orphan ownership TXT records, the ones
+// that don't have the A, AAAA or CNAME record that they own.
+type Cleaner interface {
+	// Clean deletes the orphan ownership TXT records of the hosts valid for the validator.
+	Clean(ctx context.Context, validator filter.EntryValidator) error
+}
+
+type cleaner struct {
+	cfg        Config
+	zoneFilter *regexp.Regexp
+	r53Svc     route53iface.Route53API
+	zones      adopt.ZoneIndex
+	rrsGetter  adopt.RecordSetGetter
+	logger     log.Logger
+}
+
+// NewCleaner returns a new Cleaner.
+func NewCleaner(cfg Config, r53Svc route53iface.Route53API, logger log.Logger) (Cleaner, error) {
+	r, err := regexp.Compile(cfg.ZoneFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cleaner{
+		cfg:        cfg,
+		zoneFilter: r,
+		r53Svc:     r53Svc,
+		zones:      adopt.NewZoneIndex(r53Svc),
+		rrsGetter:  adopt.NewRecordSetGetter(r53Svc),
+		logger:     logger,
+	}, nil
+}
+
+func (c *cleaner) Clean(ctx context.Context, validator filter.EntryValidator) error {
+	zones, err := c.zones.Zones(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, hz := range zones {
+		name := strings.TrimSuffix(aws.StringValue(hz.Name), ".")
+		if !c.zoneFilter.MatchString(name) {
+			c.logger.Debugf("ignoring hosted zone %s", name)
+			continue
+		}
+
+		err := c.cleanZone(ctx, aws.StringValue(hz.Id), validator)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *cleaner) cleanZone(ctx context.Context, hzID string, validator filter.EntryValidator) error {
+	logger := c.logger.With("hz", hzID)
+
+	rrs, err := c.rrsGetter.ListRecordSets(ctx, hzID)
+	if err != nil {
+		return err
+	}
+
+	changes := []route53.Change{}
+	for _, rs := range registry.NewZoneOwnership(rrs, c.cfg.Naming).Orphans() {
+		name := strings.TrimSuffix(aws.StringValue(rs.Name), ".")
+		host, _ := c.cfg.Naming.Host(name)
+		if _, err := validator.Validate(ctx, host); err != nil {
+			continue
+		}
+
+		logger := logger.With("txt", name)
+		owner, _ := registry.TXTOwner(rs)
+		if c.cfg.OwnerID != "" && owner != c.cfg.OwnerID {
+			logger.Debugf("orphan owned by %s, ignoring", owner)
+			continue
+		}
+
+		rs := rs
+		changes = append(changes, route53.Change{
+			Action:            route53.ChangeActionDelete,
+			ResourceRecordSet: &rs,
+		})
+		logger.Infof("orphan txt record set owned by %s will be deleted", owner)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	if c.cfg.DryRun {
+		logger.Infof("not deleting %d orphan txt record sets because of dry-run", len(changes))
+		return nil
+	}
+
+	_, err = adopt.SendChanges(ctx, c.r53Svc, hzID, "Remove orphan txt entries", changes)
+	if err != nil {
+		return fmt.Errorf("error deleting orphan txt record sets on %s: %s", hzID, err)
+	}
+	logger.Infof("%d orphan txt record sets deleted", len(changes))
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package cleanup_test
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/cleanup"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+)
+
+func rrs(name string, t route53.RRType, values ...string) route53.ResourceRecordSet {
+	rs := route53.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: t,
+		TTL:  aws.Int64(300),
+	}
+	for _, v := range values {
+		rs.ResourceRecords = append(rs.ResourceRecords, route53.ResourceRecord{Value: aws.String(v)})
+	}
+	return rs
+}
+
+func TestCleanerClean(t *testing.T) {
+	legacyRRS := []route53.ResourceRecordSet{
+		rrs("batman.gotham.dc.comics.", route53.RRTypeA, "10.0.0.1"),
+		rrs("batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+		rrs("robin.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+		rrs("joker.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=joker"`),
+		rrs("alfred.gotham.dc.comics.", route53.RRTypeTxt, `"v=spf1 -all"`),
+	}
+
+	tests := []struct {
+		name       string
+		prefix     string
+		ownerID    string
+		filter     string
+		dryRun     bool
+		rrs        []route53.ResourceRecordSet
+		expDeletes []string
+	}{
+		{
+			name:       "Cleaning should delete the ownership TXT records without record of all the owners.",
+			filter:     `.*`,
+			rrs:        legacyRRS,
+			expDeletes: []string{"joker.gotham.dc.comics.", "robin.gotham.dc.comics."},
+		},
+		{
+			name:       "Cleaning filtering by owner ID should delete only the orphans of that owner.",
+			filter:     `.*`,
+			ownerID:    "joker",
+			rrs:        legacyRRS,
+			expDeletes: []string{"joker.gotham.dc.comics."},
+		},
+		{
+			name:       "Cleaning filtering by host should delete only the orphans of the valid hosts.",
+			filter:     `^robin\.`,
+			rrs:        legacyRRS,
+			expDeletes: []string{"robin.gotham.dc.comics."},
+		},
+		{
+			name:   "Cleaning in dry-run mode shouldn't delete anything.",
+			filter: `.*`,
+			dryRun: true,
+			rrs:    legacyRRS,
+		},
+		{
+			name:   "Cleaning with type prefixed names should check the records of the same type.",
+			prefix: "%{record_type}-",
+			filter: `.*`,
+			rrs: []route53.ResourceRecordSet{
+				rrs("batman.gotham.dc.comics.", route53.RRTypeA, "10.0.0.1"),
+				rrs("a-batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+				rrs("cname-batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+				// Not a registry record name for the naming.
+				rrs("robin.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+			},
+			expDeletes: []string{"cname-batman.gotham.dc.comics."},
+		},
+		{
+			name:   "Cleaning with the default naming shouldn't delete the type prefixed records that own a record.",
+			filter: `.*`,
+			rrs: []route53.ResourceRecordSet{
+				rrs("batman.gotham.dc.comics.", route53.RRTypeCname, "batcave.gotham.dc.comics"),
+				rrs("batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+				rrs("cname-batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+				rrs("a-batman.gotham.dc.comics.", route53.RRTypeTxt, `"heritage=external-dns,external-dns/owner=batman"`),
+			},
+			expDeletes: []string{"a-batman.gotham.dc.comics."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(route53.ListHostedZonesRequest{
+				Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ListHostedZonesOutput{
+					HostedZones: []route53.HostedZone{{Name: aws.String("gotham.dc.comics."), Id: aws.String("gotham")}},
+				}},
+			})
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(route53.ListResourceRecordSetsRequest{
+				Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ListResourceRecordSetsOutput{ResourceRecordSets: test.rrs}},
+			})
+			gotDeletes := []string{}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Run(func(args mock.Arguments) {
+				input := args.Get(0).(*route53.ChangeResourceRecordSetsInput)
+				for _, ch := range input.ChangeBatch.Changes {
+					assert.Equal(route53.ChangeActionDelete, ch.Action)
+					gotDeletes = append(gotDeletes, aws.StringValue(ch.ResourceRecordSet.Name))
+				}
+			}).Return(route53.ChangeResourceRecordSetsRequest{
+				Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ChangeResourceRecordSetsOutput{}},
+			})
+
+			fsvc, err := filter.NewEntryValidator(test.filter, "batman")
+			require.NoError(err)
+			cl, err := cleanup.NewCleaner(cleanup.Config{
+				DryRun:     test.dryRun,
+				OwnerID:    test.ownerID,
+				ZoneFilter: `.*`,
+				Naming:     registry.Naming{Prefix: test.prefix},
+			}, mr53, log.Dummy)
+			require.NoError(err)
+
+			err = cl.Clean(context.Background(), fsvc)
+			if assert.NoError(err) {
+				sort.Strings(gotDeletes)
+				if len(test.expDeletes) == 0 {
+					mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+				} else {
+					assert.Equal(test.expDeletes, gotDeletes)
+				}
+			}
+		})
+	}
+}
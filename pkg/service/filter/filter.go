@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 
@@ -13,7 +14,7 @@ const (
 
 // EntryValidator will validate an entry.
 type EntryValidator interface {
-	Validate(host string) (*model.Entry, error)
+	Validate(ctx context.Context, host string) (*model.Entry, error)
 }
 
 type validator struct {
@@ -33,7 +34,7 @@ func NewEntryValidator(filter, ownerID string) (EntryValidator, error) {
 	}, nil
 }
 
-func (v *validator) Validate(host string) (*model.Entry, error) {
+func (v *validator) Validate(_ context.Context, host string) (*model.Entry, error) {
 	// Check the regexp filters
 	if !v.filter.MatchString(host) {
 		return nil, fmt.Errorf("%s not a valid host for the loaded filter", host)
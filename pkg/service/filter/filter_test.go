@@ -1,6 +1,7 @@
 package filter_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,7 +46,7 @@ func TestValidate(t *testing.T) {
 
 			ev, err := filter.NewEntryValidator(test.filter, test.txt)
 			require.NoError(err)
-			gotEntry, err := ev.Validate(test.host)
+			gotEntry, err := ev.Validate(context.Background(), test.host)
 
 			if test.expErr {
 				assert.Error(err)
@@ -0,0 +1,48 @@
+package journal
+
+import (
+	"context"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+)
+
+type adopter struct {
+	adSvc   adopt.RSAdopter
+	journal Journal
+	logger  log.Logger
+}
+
+// NewAdopter returns an adopter that writes the result of every adoption to the journal
+// and skips the hosts that were already completed on the journal, so interrupted runs
+// can be resumed. The hosts that failed are retried.
+func NewAdopter(adSvc adopt.RSAdopter, journal Journal, logger log.Logger) adopt.RSAdopter {
+	return &adopter{
+		adSvc:   adSvc,
+		journal: journal,
+		logger:  logger,
+	}
+}
+
+func (a *adopter) Adopt(ctx context.Context, entry *model.Entry) (*model.Result, error) {
+	if r, ok := a.journal.Completed(entry.Host); ok {
+		a.logger.With("host", entry.Host).Infof("skipping, completed on %s", r.Time)
+		res := r.Result
+		res.Message = "completed on a previous run"
+		return &res, nil
+	}
+
+	res, err := a.adSvc.Adopt(ctx, entry)
+	if res != nil {
+		if jerr := a.journal.Write(res); jerr != nil {
+			if err != nil {
+				a.logger.With("host", entry.Host).Errorf("error writing the journal: %s", jerr)
+				return res, err
+			}
+			return res, jerr
+		}
+	}
+
+	return res, err
+}
@@ -0,0 +1,225 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+// Record is a journal record with the result of the adoption of a host.
+type Record struct {
+	Time time.Time `json:"time"`
+	model.Result
+}
+
+// Journal knows how to keep track of the results of the adoptions.
+type Journal interface {
+	// Write adds the result to the journal.
+	Write(res *model.Result) error
+	// Completed returns the record of the host if it was adopted (not in dry-run) on the journal.
+	Completed(host string) (*Record, bool)
+	// Close closes the journal.
+	Close() error
+}
+
+type fileJournal struct {
+	mu        sync.Mutex
+	f         *os.File
+	completed map[string]*Record
+}
+
+// NewFileJournal returns a journal stored on a file (one JSON record per line). If the
+// file already exists the new records are appended to the existing ones. A truncated last
+// record (the run was killed while writing it) is removed from the file.
+func NewFileJournal(path string, logger log.Logger) (Journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	records, size, err := readRecords(f, logger)
+	if err == nil {
+		err = dropTruncated(f, size)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading journal %s: %s", path, err)
+	}
+
+	j := &fileJournal{
+		f:         f,
+		completed: map[string]*Record{},
+	}
+	for _, r := range records {
+		j.track(r)
+	}
+
+	return j, nil
+}
+
+func (j *fileJournal) Write(res *model.Result) error {
+	r := &Record{
+		Time:   time.Now().UTC(),
+		Result: *res,
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// A single write for each record, so an interruption doesn't leave half records.
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	j.track(r)
+	return nil
+}
+
+func (j *fileJournal) Completed(host string) (*Record, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	r, ok := j.completed[normalizeHost(host)]
+	return r, ok
+}
+
+func (j *fileJournal) Close() error {
+	return j.f.Close()
+}
+
+// track keeps the hosts that are completed, the last record of the host wins.
+func (j *fileJournal) track(r *Record) {
+	if r.DryRun {
+		return
+	}
+
+	host := normalizeHost(r.Host)
+	if r.Outcome.Success() {
+		j.completed[host] = r
+		return
+	}
+	delete(j.completed, host)
+}
+
+// ReadRecords reads the records of a journal. An invalid last record is a record truncated
+// by an interruption and it's ignored with a warning, the invalid records in the middle of
+// the journal are an error.
+func ReadRecords(r io.Reader, logger log.Logger) ([]*Record, error) {
+	records, _, err := readRecords(r, logger)
+	return records, err
+}
+
+// readRecords reads the records of a journal and returns also the size of the journal
+// without the truncated last record.
+func readRecords(r io.Reader, logger log.Logger) ([]*Record, int64, error) {
+	records := []*Record{}
+	br := bufio.NewReader(r)
+	var size int64
+	var invalid error
+	line, invalidLine := 0, 0
+	for {
+		b, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		if len(b) > 0 {
+			line++
+			if strings.TrimSpace(string(b)) != "" {
+				// Only the last record can be truncated.
+				if invalid != nil {
+					return nil, 0, fmt.Errorf("invalid record on line %d: %s", invalidLine, invalid)
+				}
+				rec := &Record{}
+				if jerr := json.Unmarshal(b, rec); jerr != nil {
+					invalid, invalidLine = jerr, line
+				} else {
+					records = append(records, rec)
+				}
+			}
+			if invalid == nil {
+				size += int64(len(b))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if invalid != nil {
+		logger.Warningf("ignoring the truncated last record of the journal on line %d: %s", invalidLine, invalid)
+	}
+
+	return records, size, nil
+}
+
+// dropTruncated removes the truncated last record from the journal file and ends the last
+// valid record line so the new records are appended after the valid ones.
+func dropTruncated(f *os.File, size int64) error {
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if st.Size() != size {
+		if err := f.Truncate(size); err != nil {
+			return err
+		}
+	}
+
+	// The last valid record needs its line end.
+	if size > 0 {
+		b := make([]byte, 1)
+		if _, err := f.ReadAt(b, size-1); err != nil {
+			return err
+		}
+		if b[0] != '\n' {
+			_, err = f.Write([]byte("\n"))
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// AdoptedResults returns the results that created the ownership TXT records of each host
+// (not in dry-run) according to the records, the last one of each host in the order of the
+// hosts. The created records that were not verified are adopted too.
+func AdoptedResults(records []*Record) []*model.Result {
+	adopted := map[string]*model.Result{}
+	hosts := []string{}
+	for _, r := range records {
+		if r.DryRun || !changed(r) {
+			continue
+		}
+		host := normalizeHost(r.Host)
+		if _, ok := adopted[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		res := r.Result
+		adopted[host] = &res
+	}
+
+	results := []*model.Result{}
+	for _, host := range hosts {
+		results = append(results, adopted[host])
+	}
+	return results
+}
+
+// changed returns true if the record changed the ownership TXT records of the host.
+func changed(r *Record) bool {
+	return r.Outcome == model.OutcomeAdopted || r.ChangeID != "" ||
+		r.Action == model.ActionCreate || r.Action == model.ActionMerge
+}
@@ -0,0 +1,204 @@
+package journal_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	madopt "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/journal"
+)
+
+func TestJournalResume(t *testing.T) {
+	tests := []struct {
+		name            string
+		previous        []*model.Result
+		hosts           []string
+		expAdoptedHosts []string
+		expAdopted      []string
+	}{
+		{
+			name: "Resuming should skip the completed hosts and retry the failed and dry-run ones.",
+			previous: []*model.Result{
+				{Host: "batman.gotham.dc.comics", Outcome: model.OutcomeAdopted, ChangeID: "/change/1"},
+				{Host: "robin.gotham.dc.comics", Outcome: model.OutcomeAlreadyOwned},
+				{Host: "joker.gotham.dc.comics", Outcome: model.OutcomeError, Message: "throttled"},
+				{Host: "alfred.gotham.dc.comics", Outcome: model.OutcomeAdopted, DryRun: true},
+			},
+			hosts:           []string{"batman.gotham.dc.comics", "robin.gotham.dc.comics", "joker.gotham.dc.comics", "alfred.gotham.dc.comics"},
+			expAdoptedHosts: []string{"joker.gotham.dc.comics", "alfred.gotham.dc.comics"},
+			expAdopted:      []string{"batman.gotham.dc.comics", "joker.gotham.dc.comics", "alfred.gotham.dc.comics"},
+		},
+		{
+			name: "A host that failed after being completed should be retried.",
+			previous: []*model.Result{
+				{Host: "batman.gotham.dc.comics", Outcome: model.OutcomeAdopted},
+				{Host: "batman.gotham.dc.comics", Outcome: model.OutcomeError},
+			},
+			hosts:           []string{"batman.gotham.dc.comics"},
+			expAdoptedHosts: []string{"batman.gotham.dc.comics"},
+			expAdopted:      []string{"batman.gotham.dc.comics"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			dir, err := ioutil.TempDir("", "journal")
+			require.NoError(err)
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "journal.jsonl")
+
+			// Previous run.
+			j, err := journal.NewFileJournal(path, log.Dummy)
+			require.NoError(err)
+			for _, res := range test.previous {
+				require.NoError(j.Write(res))
+			}
+			require.NoError(j.Close())
+
+			// Resumed run.
+			j, err = journal.NewFileJournal(path, log.Dummy)
+			require.NoError(err)
+			ma := &madopt.RSAdopter{}
+			gotAdoptedHosts := []string{}
+			ma.On("Adopt", mock.Anything, mock.Anything).Return(func(_ context.Context, e *model.Entry) *model.Result {
+				gotAdoptedHosts = append(gotAdoptedHosts, e.Host)
+				return &model.Result{Host: e.Host, Outcome: model.OutcomeAdopted}
+			}, nil)
+
+			ad := journal.NewAdopter(ma, j, log.Dummy)
+			for _, host := range test.hosts {
+				res, err := ad.Adopt(context.Background(), &model.Entry{Host: host})
+				require.NoError(err)
+				assert.True(res.Outcome.Success())
+			}
+			require.NoError(j.Close())
+			assert.Equal(test.expAdoptedHosts, gotAdoptedHosts)
+
+			// The journal has the records of both runs.
+			f, err := os.Open(path)
+			require.NoError(err)
+			defer f.Close()
+			records, err := journal.ReadRecords(f, log.Dummy)
+			require.NoError(err)
+			assert.Len(records, len(test.previous)+len(test.expAdoptedHosts))
+			assert.Equal(test.expAdopted, hosts(journal.AdoptedResults(records)))
+		})
+	}
+}
+
+func TestJournalTruncated(t *testing.T) {
+	tests := []struct {
+		name       string
+		journal    string
+		expErr     bool
+		expRecords []string
+	}{
+		{
+			name:       "A truncated last record should be ignored and the new records appended after the valid ones.",
+			journal:    "{\"host\":\"batman.gotham.dc.comics\",\"outcome\":\"adopted\"}\n{\"host\":\"robin.goth",
+			expRecords: []string{"batman.gotham.dc.comics", "joker.gotham.dc.comics"},
+		},
+		{
+			name:       "A truncated last record with line end should be ignored.",
+			journal:    "{\"host\":\"batman.gotham.dc.comics\",\"outcome\":\"adopted\"}\n{\"host\":\"robin.goth\n\n",
+			expRecords: []string{"batman.gotham.dc.comics", "joker.gotham.dc.comics"},
+		},
+		{
+			name:       "A valid last record without line end should be kept.",
+			journal:    "{\"host\":\"batman.gotham.dc.comics\",\"outcome\":\"adopted\"}",
+			expRecords: []string{"batman.gotham.dc.comics", "joker.gotham.dc.comics"},
+		},
+		{
+			name:    "An invalid record in the middle of the journal should fail.",
+			journal: "{\"host\":\"batman.gotham.dc.comics\",\"outcome\":\"adopted\"}\n{\"host\":\"robin.goth\n{\"host\":\"joker.gotham.dc.comics\",\"outcome\":\"adopted\"}\n",
+			expErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			dir, err := ioutil.TempDir("", "journal")
+			require.NoError(err)
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "journal.jsonl")
+			require.NoError(ioutil.WriteFile(path, []byte(test.journal), 0644))
+
+			j, err := journal.NewFileJournal(path, log.Dummy)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.NoError(j.Write(&model.Result{Host: "joker.gotham.dc.comics", Outcome: model.OutcomeAdopted}))
+			require.NoError(j.Close())
+
+			f, err := os.Open(path)
+			require.NoError(err)
+			defer f.Close()
+			records, err := journal.ReadRecords(f, log.Dummy)
+			require.NoError(err)
+			gotRecords := []string{}
+			for _, r := range records {
+				gotRecords = append(gotRecords, r.Host)
+			}
+			assert.Equal(test.expRecords, gotRecords)
+		})
+	}
+}
+
+func hosts(results []*model.Result) []string {
+	hosts := []string{}
+	for _, res := range results {
+		hosts = append(hosts, res.Host)
+	}
+	return hosts
+}
+
+func TestAdoptedResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		records  []*journal.Record
+		expHosts []string
+	}{
+		{
+			name: "The hosts with created ownership records should be adopted.",
+			records: []*journal.Record{
+				{Result: model.Result{Host: "batman.gotham.dc.comics", Outcome: model.OutcomeAdopted, Action: model.ActionCreate, ChangeID: "/change/1"}},
+				{Result: model.Result{Host: "robin.gotham.dc.comics", Outcome: model.OutcomeAlreadyOwned, Action: model.ActionNone}},
+				{Result: model.Result{Host: "alfred.gotham.dc.comics", Outcome: model.OutcomeAdopted, Action: model.ActionMerge, ChangeID: "/change/2"}},
+				{Result: model.Result{Host: "joker.gotham.dc.comics", Outcome: model.OutcomeAdopted, Action: model.ActionCreate, DryRun: true}},
+			},
+			expHosts: []string{"batman.gotham.dc.comics", "alfred.gotham.dc.comics"},
+		},
+		{
+			name: "An unverified host resumed as already owned should be adopted.",
+			records: []*journal.Record{
+				{Result: model.Result{Host: "batman.gotham.dc.comics", Outcome: model.OutcomeUnverified, Action: model.ActionCreate, ChangeID: "/change/1"}},
+				{Result: model.Result{Host: "batman.gotham.dc.comics", Outcome: model.OutcomeAlreadyOwned, Action: model.ActionNone}},
+			},
+			expHosts: []string{"batman.gotham.dc.comics"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(test.expHosts, hosts(journal.AdoptedResults(test.records)))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+// Writer writes the result of every host as it's adopted.
+type Writer interface {
+	// Handle writes the result of a host.
+	Handle(res *model.Result)
+	// Err returns the first error writing the results, the results after it are not written.
+	Err() error
+}
+
+type jsonWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONWriter returns a Writer that writes the results as JSON objects, one per line.
+func NewJSONWriter(w io.Writer) Writer {
+	return &jsonWriter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (j *jsonWriter) Handle(res *model.Result) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.err != nil {
+		return
+	}
+	j.err = j.enc.Encode(res)
+}
+
+func (j *jsonWriter) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.err
+}
@@ -0,0 +1,54 @@
+package output_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/output"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("wanted error")
+}
+
+func TestJSONWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	var b bytes.Buffer
+	w := output.NewJSONWriter(&b)
+	w.Handle(&model.Result{
+		Host:     "batman.dc.comic.io",
+		Zone:     "dc.comic.io",
+		ZoneID:   "/hostedzone/gotham",
+		Outcome:  model.OutcomeAdopted,
+		Action:   model.ActionCreate,
+		Records:  []model.Record{{Type: "A", TTL: 300, Values: []string{"10.0.0.1"}}},
+		TXTs:     []model.TXT{{Name: "batman.dc.comic.io", Value: `"heritage=external-dns,external-dns/owner=default"`}},
+		ChangeID: "/change/1",
+	})
+	w.Handle(&model.Result{Host: "deadpool.marvel.comic.io", Outcome: model.OutcomeSkipped, Action: model.ActionNone, Message: "not matching the filter"})
+	w.Handle(&model.Result{Host: "joker.dc.comic.io", Zone: "dc.comic.io", ZoneID: "/hostedzone/gotham", Outcome: model.OutcomeError, Action: model.ActionNone, Message: "wanted error", Error: "wanted error"})
+
+	exp := `{"host":"batman.dc.comic.io","zone":"dc.comic.io","zoneID":"/hostedzone/gotham","outcome":"adopted","action":"create","records":[{"type":"A","ttl":300,"values":["10.0.0.1"]}],"txts":[{"name":"batman.dc.comic.io","value":"\"heritage=external-dns,external-dns/owner=default\""}],"changeID":"/change/1"}
+{"host":"deadpool.marvel.comic.io","outcome":"skipped","action":"none","message":"not matching the filter"}
+{"host":"joker.dc.comic.io","zone":"dc.comic.io","zoneID":"/hostedzone/gotham","outcome":"error","action":"none","message":"wanted error","error":"wanted error"}
+`
+	if assert.NoError(w.Err()) {
+		assert.Equal(exp, b.String())
+	}
+}
+
+func TestJSONWriterError(t *testing.T) {
+	assert := assert.New(t)
+
+	w := output.NewJSONWriter(failWriter{})
+	w.Handle(&model.Result{Host: "batman.dc.comic.io", Outcome: model.OutcomeAdopted})
+
+	assert.Error(w.Err())
+}
@@ -0,0 +1,173 @@
+package owner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+// DefaultRule is the rule of the hosts that get the default owner ID.
+const DefaultRule = "default"
+
+// ErrNoRule is returned when no rule matches the host and there is no default owner ID.
+var ErrNoRule = errors.New("no owner rule matches the host")
+
+// Rule assigns an owner ID to the hosts that match all its regexes, the empty ones match
+// everything.
+type Rule struct {
+	// Name identifies the rule on the results.
+	Name string `json:"name"`
+	// Host is the regex that matches the host.
+	Host string `json:"host,omitempty"`
+	// Zone is the regex that matches the hosted zone name.
+	Zone string `json:"zone,omitempty"`
+	// Target is the regex that matches any of the values or alias targets of the host records.
+	Target string `json:"target,omitempty"`
+	// OwnerID is the owner ID of the matching hosts.
+	OwnerID string `json:"ownerID"`
+}
+
+// Rules are the ordered owner rules, the first rule that matches a host wins.
+type Rules struct {
+	Rules []Rule `json:"rules"`
+	// DefaultOwnerID is the owner ID of the hosts that don't match any rule, these are not
+	// adopted if it's empty.
+	DefaultOwnerID string `json:"defaultOwnerID,omitempty"`
+}
+
+// ReadRules reads the JSON rules.
+func ReadRules(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(rules); err != nil {
+		return nil, fmt.Errorf("invalid owner rules: %s", err)
+	}
+	return rules, nil
+}
+
+// LoadRules loads the JSON rules file.
+func LoadRules(path string) (*Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadRules(f)
+}
+
+// Assignment is the owner ID assigned to a host and the rule that assigned it.
+type Assignment struct {
+	OwnerID string
+	Rule    string
+}
+
+// Assigner knows how to assign the owner ID of a host.
+type Assigner interface {
+	// Assign returns the owner ID of the host from the hosted zone and the records of the host,
+	// ErrNoRule if it doesn't have one.
+	Assign(host, zone string, records []model.Record) (*Assignment, error)
+}
+
+type rule struct {
+	name    string
+	host    *regexp.Regexp
+	zone    *regexp.Regexp
+	target  *regexp.Regexp
+	ownerID string
+}
+
+type assigner struct {
+	rules          []rule
+	defaultOwnerID string
+}
+
+// NewAssigner returns a new Assigner with the rules.
+func NewAssigner(rules Rules) (Assigner, error) {
+	a := &assigner{defaultOwnerID: rules.DefaultOwnerID}
+	names := map[string]bool{}
+	for i, r := range rules.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("owner rule %d without name", i)
+		}
+		if names[r.Name] || r.Name == DefaultRule {
+			return nil, fmt.Errorf("duplicated owner rule %q", r.Name)
+		}
+		names[r.Name] = true
+		if r.OwnerID == "" {
+			return nil, fmt.Errorf("owner rule %q without owner ID", r.Name)
+		}
+
+		ar := rule{name: r.Name, ownerID: r.OwnerID}
+		var err error
+		if ar.host, err = compile(r.Host); err != nil {
+			return nil, fmt.Errorf("invalid host of owner rule %q: %s", r.Name, err)
+		}
+		if ar.zone, err = compile(r.Zone); err != nil {
+			return nil, fmt.Errorf("invalid zone of owner rule %q: %s", r.Name, err)
+		}
+		if ar.target, err = compile(r.Target); err != nil {
+			return nil, fmt.Errorf("invalid target of owner rule %q: %s", r.Name, err)
+		}
+		a.rules = append(a.rules, ar)
+	}
+	return a, nil
+}
+
+// compile compiles the regex, nil if it's empty.
+func compile(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
+
+func (a *assigner) Assign(host, zone string, records []model.Record) (*Assignment, error) {
+	host = strings.TrimSuffix(host, ".")
+	zone = strings.TrimSuffix(zone, ".")
+	for _, r := range a.rules {
+		if r.host != nil && !r.host.MatchString(host) {
+			continue
+		}
+		if r.zone != nil && !r.zone.MatchString(zone) {
+			continue
+		}
+		if r.target != nil && !matchTarget(r.target, records) {
+			continue
+		}
+		return &Assignment{OwnerID: r.ownerID, Rule: r.name}, nil
+	}
+
+	if a.defaultOwnerID != "" {
+		return &Assignment{OwnerID: a.defaultOwnerID, Rule: DefaultRule}, nil
+	}
+	return nil, ErrNoRule
+}
+
+// matchTarget returns true if any of the values or alias targets of the A, AAAA or CNAME
+// records matches.
+func matchTarget(target *regexp.Regexp, records []model.Record) bool {
+	for _, r := range records {
+		switch r.Type {
+		case "A", "AAAA", "CNAME":
+		default:
+			continue
+		}
+		if r.Alias != "" && target.MatchString(strings.TrimSuffix(r.Alias, ".")) {
+			return true
+		}
+		for _, v := range r.Values {
+			if target.MatchString(strings.TrimSuffix(v, ".")) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package owner_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/owner"
+)
+
+func TestAssignerAssign(t *testing.T) {
+	rules := owner.Rules{Rules: []owner.Rule{
+		{Name: "gotham-elb", Target: `gotham-elb-\d+\..*\.elb\.amazonaws\.com$`, OwnerID: "batman"},
+		{Name: "marvel", Host: `\.marvel\.comic\.io$`, OwnerID: "deadpool"},
+		{Name: "dc", Zone: `^dc\.comic\.io$`, OwnerID: "superman"},
+	}}
+
+	tests := []struct {
+		name          string
+		defaultOwner  string
+		host          string
+		zone          string
+		records       []model.Record
+		expAssignment *owner.Assignment
+		expErr        bool
+	}{
+		{
+			name:          "A host pointing to a matching target should get the owner of the target rule.",
+			host:          "robin.dc.comic.io",
+			zone:          "dc.comic.io",
+			records:       []model.Record{{Type: "A", Alias: "dualstack.gotham-elb-123.eu-west-1.elb.amazonaws.com."}},
+			expAssignment: &owner.Assignment{OwnerID: "batman", Rule: "gotham-elb"},
+		},
+		{
+			name:          "A host matching multiple rules should get the owner of the first rule.",
+			host:          "wolverine.marvel.comic.io.",
+			zone:          "dc.comic.io",
+			records:       []model.Record{{Type: "CNAME", Values: []string{"metropolis-elb-123.eu-west-1.elb.amazonaws.com"}}},
+			expAssignment: &owner.Assignment{OwnerID: "deadpool", Rule: "marvel"},
+		},
+		{
+			name:          "A host of a matching zone should get the owner of the zone rule.",
+			host:          "superman.dc.comic.io",
+			zone:          "dc.comic.io.",
+			expAssignment: &owner.Assignment{OwnerID: "superman", Rule: "dc"},
+		},
+		{
+			name:          "A host not matching any rule should get the default owner.",
+			defaultOwner:  "joker",
+			host:          "joker.villains.comic.io",
+			zone:          "villains.comic.io",
+			expAssignment: &owner.Assignment{OwnerID: "joker", Rule: owner.DefaultRule},
+		},
+		{
+			name:    "A host not matching any rule without default owner should fail.",
+			host:    "joker.villains.comic.io",
+			zone:    "villains.comic.io",
+			records: []model.Record{{Type: "TXT", Values: []string{"gotham-elb-1.eu-west-1.elb.amazonaws.com"}}},
+			expErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rs := rules
+			rs.DefaultOwnerID = test.defaultOwner
+			a, err := owner.NewAssigner(rs)
+			if !assert.NoError(err) {
+				return
+			}
+			got, err := a.Assign(test.host, test.zone, test.records)
+			if test.expErr {
+				assert.Equal(owner.ErrNoRule, err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expAssignment, got)
+			}
+		})
+	}
+}
+
+func TestNewAssignerInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{
+			name:  "A rule without name should fail.",
+			rules: `{"rules": [{"host": "\\.dc\\.comic\\.io$", "ownerID": "batman"}]}`,
+		},
+		{
+			name:  "A rule without owner ID should fail.",
+			rules: `{"rules": [{"name": "dc", "host": "\\.dc\\.comic\\.io$"}]}`,
+		},
+		{
+			name:  "Duplicated rules should fail.",
+			rules: `{"rules": [{"name": "dc", "ownerID": "batman"}, {"name": "dc", "ownerID": "superman"}]}`,
+		},
+		{
+			name:  "A rule with an invalid regex should fail.",
+			rules: `{"rules": [{"name": "dc", "zone": "(", "ownerID": "batman"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rules, err := owner.ReadRules(bytes.NewBufferString(test.rules))
+			if assert.NoError(err) {
+				_, err = owner.NewAssigner(*rules)
+				assert.Error(err)
+			}
+		})
+	}
+}
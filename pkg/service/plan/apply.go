@@ -0,0 +1,131 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+)
+
+// ApplyConfig is the configuration of the applier.
+type ApplyConfig struct {
+	// DryRun will not make any change on Route53.
+	DryRun bool
+	// OnDrift is what to do when the record sets changed since the plan.
+	OnDrift DriftPolicy
+}
+
+// Applier knows how to apply a plan.
+type Applier interface {
+	// Apply applies the changes of the plan, each host in its own change batch. Before
+	// applying, the record sets are checked against the state that the plan was based on.
+	Apply(ctx context.Context, plan *Plan) error
+}
+
+type applier struct {
+	cfg       ApplyConfig
+	r53Svc    route53iface.Route53API
+	rrsGetter adopt.RecordSetGetter
+	logger    log.Logger
+}
+
+// NewApplier returns a new Applier.
+func NewApplier(cfg ApplyConfig, r53Svc route53iface.Route53API, logger log.Logger) (Applier, error) {
+	switch cfg.OnDrift {
+	case RefuseDriftPolicy, SkipDriftPolicy:
+	default:
+		return nil, fmt.Errorf("invalid drift policy %q", cfg.OnDrift)
+	}
+
+	return &applier{
+		cfg:       cfg,
+		r53Svc:    r53Svc,
+		rrsGetter: adopt.NewRecordSetGetter(r53Svc),
+		logger:    logger,
+	}, nil
+}
+
+func (a *applier) Apply(ctx context.Context, plan *Plan) error {
+	drifted, err := a.drifted(ctx, plan)
+	if err != nil {
+		return err
+	}
+	if len(drifted) > 0 && a.cfg.OnDrift == RefuseDriftPolicy {
+		return fmt.Errorf("stale plan, %d hosts changed since the plan", len(drifted))
+	}
+
+	total, failed := 0, 0
+	for _, z := range plan.Zones {
+		for _, h := range z.Hosts {
+			total++
+			logger := a.logger.With("hz", z.ID).With("host", h.Host)
+			if drifted[h] {
+				logger.Warningf("skipping host because it changed since the plan")
+				continue
+			}
+			err := a.applyHost(ctx, z.ID, h, logger)
+			if err != nil {
+				failed++
+				logger.Errorf("error applying the plan: %s", err)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d hosts of the plan failed", failed, total)
+	}
+	return nil
+}
+
+// drifted returns the hosts of the plan whose record sets changed since the plan.
+func (a *applier) drifted(ctx context.Context, plan *Plan) (map[*Host]bool, error) {
+	drifted := map[*Host]bool{}
+	for _, z := range plan.Zones {
+		zonerrs, err := a.rrsGetter.ListRecordSets(ctx, z.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range z.Hosts {
+			logger := a.logger.With("hz", z.ID).With("host", h.Host)
+			diffs := hostDrift(h, zonerrs)
+			for _, d := range diffs {
+				logger.Warningf("drift: %s", d)
+			}
+			if len(diffs) > 0 {
+				drifted[h] = true
+			}
+		}
+	}
+
+	return drifted, nil
+}
+
+func (a *applier) applyHost(ctx context.Context, hzID string, h *Host, logger log.Logger) error {
+	changes := []route53.Change{}
+	for _, ch := range h.Changes {
+		rs := ch.RecordSet.ResourceRecordSet()
+		changes = append(changes, route53.Change{
+			Action:            route53.ChangeAction(ch.Action),
+			ResourceRecordSet: &rs,
+		})
+		logger.Infof("%s %s %s", ch.Action, ch.RecordSet.Type, ch.RecordSet.Name)
+	}
+
+	if a.cfg.DryRun {
+		logger.Infof("not applying %d changes because of dry-run", len(changes))
+		return nil
+	}
+
+	_, err := adopt.SendChanges(ctx, a.r53Svc, hzID, "Apply adoption plan", changes)
+	if err != nil {
+		return err
+	}
+	logger.Infof("%d changes applied", len(changes))
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package plan
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// DriftPolicy is what to do with the hosts whose record sets changed since the plan.
+type DriftPolicy string
+
+// Drift policies.
+const (
+	// RefuseDriftPolicy doesn't apply anything if any host drifted.
+	RefuseDriftPolicy DriftPolicy = "refuse"
+	// SkipDriftPolicy applies only the hosts that didn't drift.
+	SkipDriftPolicy DriftPolicy = "skip"
+)
+
+// hostDrift returns the differences between the record sets that the plan of the host
+// was based on and the current record sets of the zone.
+func hostDrift(h *Host, zonerrs []route53.ResourceRecordSet) []string {
+	// The record sets of the names that the plan depends on.
+	names := map[string]bool{normalizeName(h.Host): true}
+	for _, rs := range h.Current {
+		names[normalizeName(rs.Name)] = true
+	}
+	for _, ch := range h.Changes {
+		names[normalizeName(ch.RecordSet.Name)] = true
+	}
+
+	planned := map[string]RecordSet{}
+	for _, rs := range h.Current {
+		planned[recordSetKey(rs)] = rs
+	}
+	current := map[string]RecordSet{}
+	for _, rs := range zonerrs {
+		if !names[normalizeName(aws.StringValue(rs.Name))] {
+			continue
+		}
+		r := newRecordSet(rs)
+		current[recordSetKey(r)] = r
+	}
+
+	diffs := []string{}
+	for k, p := range planned {
+		c, ok := current[k]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("%s deleted since the plan", k))
+		case !reflect.DeepEqual(p, c):
+			diffs = append(diffs, fmt.Sprintf("%s changed since the plan: %s -> %s", k, formatRecordSet(p), formatRecordSet(c)))
+		}
+	}
+	for k, c := range current {
+		if _, ok := planned[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s created since the plan: %s", k, formatRecordSet(c)))
+		}
+	}
+	sort.Strings(diffs)
+
+	return diffs
+}
+
+func recordSetKey(rs RecordSet) string {
+	k := fmt.Sprintf("%s %s", rs.Type, normalizeName(rs.Name))
+	if rs.SetIdentifier != "" {
+		k = fmt.Sprintf("%s (%s)", k, rs.SetIdentifier)
+	}
+	return k
+}
+
+func formatRecordSet(rs RecordSet) string {
+	ttl := "-"
+	if rs.TTL != nil {
+		ttl = fmt.Sprintf("%d", *rs.TTL)
+	}
+	targets := strings.Join(rs.Values, ",")
+	if rs.Alias != nil {
+		targets = "alias:" + rs.Alias.DNSName
+	}
+	return fmt.Sprintf("ttl=%s [%s]", ttl, targets)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,152 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+)
+
+// Version is the version of the plan file format.
+const Version = 1
+
+// Plan are the Route53 changes of an adoption grouped by hosted zone.
+type Plan struct {
+	Version int `json:"version"`
+	// OwnerID is the default owner ID, with owner rules each host has its own owner.
+	OwnerID string  `json:"ownerID"`
+	Zones   []*Zone `json:"zones"`
+}
+
+// Zone are the changes of a hosted zone.
+type Zone struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Hosts []*Host `json:"hosts"`
+}
+
+// Host are the changes to adopt a host, they are applied in the same change batch.
+type Host struct {
+	Host string `json:"host"`
+	// Owner is the owner ID of the ownership TXT records of the host, Rule the owner rule
+	// that assigned it.
+	Owner   string   `json:"owner,omitempty"`
+	Rule    string   `json:"rule,omitempty"`
+	Changes []Change `json:"changes"`
+	// Current is the state of the record sets when the plan was made.
+	Current []RecordSet `json:"current"`
+}
+
+// Change is a Route53 change.
+type Change struct {
+	Action    string    `json:"action"`
+	RecordSet RecordSet `json:"recordSet"`
+}
+
+// Planner records the changes of the adoptions and returns them as a plan.
+type Planner interface {
+	adopt.ChangeRecorder
+	// Plan returns the plan of the recorded changes.
+	Plan() *Plan
+}
+
+type planner struct {
+	ownerID string
+
+	mu    sync.Mutex
+	zones map[string]*Zone
+}
+
+// NewPlanner returns a new Planner.
+func NewPlanner(ownerID string) Planner {
+	return &planner{
+		ownerID: ownerID,
+		zones:   map[string]*Zone{},
+	}
+}
+
+func (p *planner) Record(cs *adopt.ChangeSet) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	z, ok := p.zones[cs.ZoneID]
+	if !ok {
+		z = &Zone{ID: cs.ZoneID, Name: cs.Zone}
+		p.zones[cs.ZoneID] = z
+	}
+
+	h := &Host{
+		Host:    cs.Host,
+		Owner:   cs.Owner,
+		Rule:    cs.Rule,
+		Changes: []Change{},
+		Current: []RecordSet{},
+	}
+	for _, ch := range cs.Changes {
+		h.Changes = append(h.Changes, Change{
+			Action:    string(ch.Action),
+			RecordSet: newRecordSet(*ch.ResourceRecordSet),
+		})
+	}
+	for _, rs := range cs.Current {
+		h.Current = append(h.Current, newRecordSet(rs))
+	}
+	z.Hosts = append(z.Hosts, h)
+}
+
+func (p *planner) Plan() *Plan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	plan := &Plan{
+		Version: Version,
+		OwnerID: p.ownerID,
+		Zones:   []*Zone{},
+	}
+	for _, z := range p.zones {
+		sort.Slice(z.Hosts, func(i, j int) bool { return z.Hosts[i].Host < z.Hosts[j].Host })
+		plan.Zones = append(plan.Zones, z)
+	}
+	sort.Slice(plan.Zones, func(i, j int) bool { return plan.Zones[i].Name < plan.Zones[j].Name })
+
+	return plan
+}
+
+// WritePlan writes the plan file.
+func WritePlan(w io.Writer, plan *Plan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
+
+// ReadPlan reads a plan file, only the plans with the same version are valid.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	plan := &Plan{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(plan); err != nil {
+		return nil, fmt.Errorf("invalid plan: %s", err)
+	}
+	if plan.Version != Version {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", plan.Version, Version)
+	}
+
+	for _, z := range plan.Zones {
+		for _, h := range z.Hosts {
+			for _, ch := range h.Changes {
+				switch route53.ChangeAction(ch.Action) {
+				case route53.ChangeActionCreate, route53.ChangeActionUpsert, route53.ChangeActionDelete:
+				default:
+					return nil, fmt.Errorf("invalid plan: unknown action %q on %s", ch.Action, h.Host)
+				}
+			}
+		}
+	}
+
+	return plan, nil
+}
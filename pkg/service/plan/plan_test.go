@@ -0,0 +1,291 @@
+package plan_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	mowner "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/owner"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/owner"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/plan"
+)
+
+func mockRoute53(rrs []route53.ResourceRecordSet) *mroute53iface.Route53API {
+	mr53 := &mroute53iface.Route53API{}
+	mr53.On("ListHostedZonesRequest", mock.Anything).Return(route53.ListHostedZonesRequest{
+		Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ListHostedZonesOutput{
+			HostedZones: []route53.HostedZone{{Name: aws.String("gotham.dc.comics."), Id: aws.String("gotham")}},
+		}},
+	})
+	mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(route53.ListResourceRecordSetsRequest{
+		Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ListResourceRecordSetsOutput{ResourceRecordSets: rrs}},
+	})
+	return mr53
+}
+
+func TestPlanAndApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		dryRun     bool
+		hosts      []string
+		rrs        []route53.ResourceRecordSet
+		expHosts   []string
+		expChanges map[string][]string
+	}{
+		{
+			name:  "Planning should record the changes of the adoptable hosts and applying should send them per host.",
+			hosts: []string{"robin.gotham.dc.comics", "batman.gotham.dc.comics", "joker.gotham.dc.comics"},
+			rrs: []route53.ResourceRecordSet{
+				{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.1")}}},
+				{Name: aws.String("robin.gotham.dc.comics."), Type: route53.RRTypeCname, SetIdentifier: aws.String("blue"), Weight: aws.Int64(10), TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("batcave.gotham.com")}}},
+			},
+			expHosts: []string{"batman.gotham.dc.comics", "robin.gotham.dc.comics"},
+			expChanges: map[string][]string{
+				"batman.gotham.dc.comics": {"CREATE TXT batman.gotham.dc.comics."},
+				"robin.gotham.dc.comics":  {"CREATE TXT robin.gotham.dc.comics. blue"},
+			},
+		},
+		{
+			name:  "Applying in dry-run mode shouldn't change anything.",
+			hosts: []string{"batman.gotham.dc.comics"},
+			rrs: []route53.ResourceRecordSet{
+				{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.1")}}},
+			},
+			dryRun:   true,
+			expHosts: []string{"batman.gotham.dc.comics"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Plan.
+			mr53 := mockRoute53(test.rrs)
+			pl := plan.NewPlanner("batman")
+			ad := adopt.NewRSAdopter(adopt.Config{Recorder: pl}, mr53, log.Dummy)
+			for _, host := range test.hosts {
+				_, err := ad.Adopt(context.Background(), &model.Entry{Host: host, TXT: "heritage=external-dns,external-dns/owner=batman"})
+				require.NoError(err)
+			}
+			mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+
+			// Write and read the plan file.
+			var b bytes.Buffer
+			require.NoError(plan.WritePlan(&b, pl.Plan()))
+			p, err := plan.ReadPlan(&b)
+			require.NoError(err)
+			require.Len(p.Zones, 1)
+			gotHosts := []string{}
+			for _, h := range p.Zones[0].Hosts {
+				gotHosts = append(gotHosts, h.Host)
+				assert.NotEmpty(h.Current)
+				assert.Equal("batman", h.Owner)
+			}
+			assert.Equal(test.expHosts, gotHosts)
+
+			// Apply.
+			gotChanges := map[string][]string{}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Run(func(args mock.Arguments) {
+				input := args.Get(0).(*route53.ChangeResourceRecordSetsInput)
+				assert.Equal("gotham", aws.StringValue(input.HostedZoneId))
+				for _, ch := range input.ChangeBatch.Changes {
+					rs := ch.ResourceRecordSet
+					host := strings.TrimSuffix(aws.StringValue(rs.Name), ".")
+					desc := strings.TrimSpace(strings.Join([]string{string(ch.Action), string(rs.Type), aws.StringValue(rs.Name), aws.StringValue(rs.SetIdentifier)}, " "))
+					gotChanges[host] = append(gotChanges[host], desc)
+				}
+			}).Return(route53.ChangeResourceRecordSetsRequest{
+				Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ChangeResourceRecordSetsOutput{}},
+			})
+
+			ap, err := plan.NewApplier(plan.ApplyConfig{DryRun: test.dryRun, OnDrift: plan.RefuseDriftPolicy}, mr53, log.Dummy)
+			require.NoError(err)
+			err = ap.Apply(context.Background(), p)
+			if assert.NoError(err) {
+				if test.dryRun {
+					mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+				} else {
+					assert.Equal(test.expChanges, gotChanges)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanOwners(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mr53 := mockRoute53([]route53.ResourceRecordSet{
+		{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.1")}}},
+		{Name: aws.String("robin.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.2")}}},
+	})
+	mo := &mowner.Assigner{}
+	mo.On("Assign", "batman.gotham.dc.comics", "gotham.dc.comics", mock.Anything).Return(&owner.Assignment{OwnerID: "batman", Rule: "heroes"}, nil)
+	mo.On("Assign", "robin.gotham.dc.comics", "gotham.dc.comics", mock.Anything).Return(&owner.Assignment{OwnerID: "robin", Rule: "sidekicks"}, nil)
+
+	pl := plan.NewPlanner("default")
+	ad := adopt.NewRSAdopter(adopt.Config{Recorder: pl, Owners: mo}, mr53, log.Dummy)
+	for _, host := range []string{"batman.gotham.dc.comics", "robin.gotham.dc.comics"} {
+		_, err := ad.Adopt(context.Background(), &model.Entry{Host: host, TXT: "heritage=external-dns,external-dns/owner=default"})
+		require.NoError(err)
+	}
+
+	// Each host has the owner assigned by its rule.
+	p := pl.Plan()
+	require.Len(p.Zones, 1)
+	gotOwners := map[string]string{}
+	for _, h := range p.Zones[0].Hosts {
+		gotOwners[h.Host] = h.Owner + "/" + h.Rule
+	}
+	assert.Equal(map[string]string{
+		"batman.gotham.dc.comics": "batman/heroes",
+		"robin.gotham.dc.comics":  "robin/sidekicks",
+	}, gotOwners)
+}
+
+func TestReadPlan(t *testing.T) {
+	tests := []struct {
+		name   string
+		plan   string
+		expErr bool
+	}{
+		{
+			name: "A valid plan should be read.",
+			plan: `{"version": 1, "ownerID": "batman", "zones": [{"id": "gotham", "name": "gotham.dc.comics", "hosts": [{"host": "batman.gotham.dc.comics", "changes": [{"action": "CREATE", "recordSet": {"name": "batman.gotham.dc.comics", "type": "TXT", "values": ["\"heritage=external-dns\""]}}], "current": []}]}]}`,
+		},
+		{
+			name:   "A plan with other version should fail.",
+			plan:   `{"version": 2, "ownerID": "batman", "zones": []}`,
+			expErr: true,
+		},
+		{
+			name:   "A plan with unknown actions should fail.",
+			plan:   `{"version": 1, "ownerID": "batman", "zones": [{"id": "gotham", "name": "gotham.dc.comics", "hosts": [{"host": "batman.gotham.dc.comics", "changes": [{"action": "DESTROY", "recordSet": {"name": "batman.gotham.dc.comics", "type": "TXT"}}]}]}]}`,
+			expErr: true,
+		},
+		{
+			name:   "A plan with unknown fields should fail.",
+			plan:   `{"version": 1, "owner": "batman", "zones": []}`,
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := plan.ReadPlan(strings.NewReader(test.plan))
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestApplyStalePlan(t *testing.T) {
+	planRRS := []route53.ResourceRecordSet{
+		{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.1")}}},
+		{Name: aws.String("robin.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.2")}}},
+	}
+
+	tests := []struct {
+		name     string
+		onDrift  plan.DriftPolicy
+		applyRRS []route53.ResourceRecordSet
+		expHosts []string
+		expErr   bool
+	}{
+		{
+			name:     "Applying a plan without changes on the records should apply all the hosts.",
+			onDrift:  plan.RefuseDriftPolicy,
+			applyRRS: planRRS,
+			expHosts: []string{"batman.gotham.dc.comics.", "robin.gotham.dc.comics."},
+		},
+		{
+			name:    "Applying a plan with changed records refusing stale plans should not apply anything.",
+			onDrift: plan.RefuseDriftPolicy,
+			applyRRS: []route53.ResourceRecordSet{
+				{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(60), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.100")}}},
+				planRRS[1],
+			},
+			expErr: true,
+		},
+		{
+			name:    "Applying a plan with changed records skipping the stale hosts should apply the other hosts.",
+			onDrift: plan.SkipDriftPolicy,
+			applyRRS: []route53.ResourceRecordSet{
+				{Name: aws.String("batman.gotham.dc.comics."), Type: route53.RRTypeA, TTL: aws.Int64(300), ResourceRecords: []route53.ResourceRecord{{Value: aws.String("10.0.0.1")}}},
+				planRRS[1],
+			},
+			expHosts: []string{"robin.gotham.dc.comics."},
+		},
+		{
+			name:    "Applying a plan with new records on the ownership names skipping the stale hosts should apply the other hosts.",
+			onDrift: plan.SkipDriftPolicy,
+			applyRRS: append([]route53.ResourceRecordSet{
+				{Name: aws.String("robin.gotham.dc.comics."), Type: route53.RRTypeTxt, TTL: aws.Int64(300), ResourceRecords: []route53.ResourceRecord{{Value: aws.String(`"heritage=external-dns,external-dns/owner=joker"`)}}},
+			}, planRRS...),
+			expHosts: []string{"batman.gotham.dc.comics."},
+		},
+		{
+			name:    "Applying a plan with deleted records skipping the stale hosts should apply the other hosts.",
+			onDrift: plan.SkipDriftPolicy,
+			applyRRS: []route53.ResourceRecordSet{
+				planRRS[1],
+			},
+			expHosts: []string{"robin.gotham.dc.comics."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Plan.
+			pl := plan.NewPlanner("batman")
+			ad := adopt.NewRSAdopter(adopt.Config{Recorder: pl}, mockRoute53(planRRS), log.Dummy)
+			for _, host := range []string{"batman.gotham.dc.comics", "robin.gotham.dc.comics"} {
+				_, err := ad.Adopt(context.Background(), &model.Entry{Host: host, TXT: "heritage=external-dns,external-dns/owner=batman"})
+				require.NoError(err)
+			}
+
+			// Apply on the changed zone.
+			mr53 := mockRoute53(test.applyRRS)
+			gotHosts := []string{}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Run(func(args mock.Arguments) {
+				input := args.Get(0).(*route53.ChangeResourceRecordSetsInput)
+				gotHosts = append(gotHosts, aws.StringValue(input.ChangeBatch.Changes[0].ResourceRecordSet.Name))
+			}).Return(route53.ChangeResourceRecordSetsRequest{
+				Request: &aws.Request{HTTPRequest: &http.Request{}, Data: &route53.ChangeResourceRecordSetsOutput{}},
+			})
+
+			ap, err := plan.NewApplier(plan.ApplyConfig{OnDrift: test.onDrift}, mr53, log.Dummy)
+			require.NoError(err)
+			err = ap.Apply(context.Background(), pl.Plan())
+			if test.expErr {
+				assert.Error(err)
+				mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expHosts, gotHosts)
+			}
+		})
+	}
+}
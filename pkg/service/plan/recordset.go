@@ -0,0 +1,113 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// RecordSet is a Route53 record set on the plan.
+type RecordSet struct {
+	Name          string       `json:"name"`
+	Type          string       `json:"type"`
+	SetIdentifier string       `json:"setIdentifier,omitempty"`
+	TTL           *int64       `json:"ttl,omitempty"`
+	Values        []string     `json:"values,omitempty"`
+	Alias         *Alias       `json:"alias,omitempty"`
+	Weight        *int64       `json:"weight,omitempty"`
+	Region        string       `json:"region,omitempty"`
+	Failover      string       `json:"failover,omitempty"`
+	GeoLocation   *GeoLocation `json:"geoLocation,omitempty"`
+	HealthCheckID string       `json:"healthCheckID,omitempty"`
+}
+
+// Alias is the target of an alias record set.
+type Alias struct {
+	DNSName              string `json:"dnsName"`
+	HostedZoneID         string `json:"hostedZoneID"`
+	EvaluateTargetHealth bool   `json:"evaluateTargetHealth"`
+}
+
+// GeoLocation is the location of a geolocation record set.
+type GeoLocation struct {
+	ContinentCode   string `json:"continentCode,omitempty"`
+	CountryCode     string `json:"countryCode,omitempty"`
+	SubdivisionCode string `json:"subdivisionCode,omitempty"`
+}
+
+func newRecordSet(rs route53.ResourceRecordSet) RecordSet {
+	r := RecordSet{
+		Name:          strings.TrimSuffix(aws.StringValue(rs.Name), "."),
+		Type:          string(rs.Type),
+		SetIdentifier: aws.StringValue(rs.SetIdentifier),
+		TTL:           rs.TTL,
+		Weight:        rs.Weight,
+		Region:        string(rs.Region),
+		Failover:      string(rs.Failover),
+		HealthCheckID: aws.StringValue(rs.HealthCheckId),
+	}
+	for _, v := range rs.ResourceRecords {
+		r.Values = append(r.Values, aws.StringValue(v.Value))
+	}
+	if rs.AliasTarget != nil {
+		r.Alias = &Alias{
+			DNSName:              aws.StringValue(rs.AliasTarget.DNSName),
+			HostedZoneID:         aws.StringValue(rs.AliasTarget.HostedZoneId),
+			EvaluateTargetHealth: aws.BoolValue(rs.AliasTarget.EvaluateTargetHealth),
+		}
+	}
+	if rs.GeoLocation != nil {
+		r.GeoLocation = &GeoLocation{
+			ContinentCode:   aws.StringValue(rs.GeoLocation.ContinentCode),
+			CountryCode:     aws.StringValue(rs.GeoLocation.CountryCode),
+			SubdivisionCode: aws.StringValue(rs.GeoLocation.SubdivisionCode),
+		}
+	}
+
+	return r
+}
+
+// ResourceRecordSet returns the Route53 record set.
+func (r RecordSet) ResourceRecordSet() route53.ResourceRecordSet {
+	rs := route53.ResourceRecordSet{
+		Name:   aws.String(r.Name + "."),
+		Type:   route53.RRType(r.Type),
+		TTL:    r.TTL,
+		Weight: r.Weight,
+		Region: route53.ResourceRecordSetRegion(r.Region),
+	}
+	if r.SetIdentifier != "" {
+		rs.SetIdentifier = aws.String(r.SetIdentifier)
+	}
+	if r.Failover != "" {
+		rs.Failover = route53.ResourceRecordSetFailover(r.Failover)
+	}
+	if r.HealthCheckID != "" {
+		rs.HealthCheckId = aws.String(r.HealthCheckID)
+	}
+	for _, v := range r.Values {
+		rs.ResourceRecords = append(rs.ResourceRecords, route53.ResourceRecord{Value: aws.String(v)})
+	}
+	if r.Alias != nil {
+		rs.AliasTarget = &route53.AliasTarget{
+			DNSName:              aws.String(r.Alias.DNSName),
+			HostedZoneId:         aws.String(r.Alias.HostedZoneID),
+			EvaluateTargetHealth: aws.Bool(r.Alias.EvaluateTargetHealth),
+		}
+	}
+	if r.GeoLocation != nil {
+		rs.GeoLocation = &route53.GeoLocation{}
+		if r.GeoLocation.ContinentCode != "" {
+			rs.GeoLocation.ContinentCode = aws.String(r.GeoLocation.ContinentCode)
+		}
+		if r.GeoLocation.CountryCode != "" {
+			rs.GeoLocation.CountryCode = aws.String(r.GeoLocation.CountryCode)
+		}
+		if r.GeoLocation.SubdivisionCode != "" {
+			rs.GeoLocation.SubdivisionCode = aws.String(r.GeoLocation.SubdivisionCode)
+		}
+	}
+
+	return rs
+}
@@ -0,0 +1,38 @@
+package process
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+// ReadEndpoints reads a stream of endpoints in JSON format (one per line).
+func ReadEndpoints(r io.Reader) ([]*model.Endpoint, error) {
+	eps := []*model.Endpoint{}
+
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		data := strings.TrimSpace(sc.Text())
+		if data == "" {
+			continue
+		}
+
+		ep := &model.Endpoint{}
+		if err := json.Unmarshal([]byte(data), ep); err != nil {
+			return nil, fmt.Errorf("invalid endpoint on line %d: %s", line, err)
+		}
+		if ep.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint on line %d: missing host", line)
+		}
+		eps = append(eps, ep)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return eps, nil
+}
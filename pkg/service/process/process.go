@@ -2,60 +2,316 @@ package process
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
 	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
 	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/watch"
 )
 
-// StreamAdopter knows how to process a stream.
+// StreamAdopter knows how to process a stream. Each line of the stream has a host
+// and optionally the ingress (namespace/name) of the host separated by a space.
+// When the context is done the stream stops reading hosts, the in-flight adoptions
+// finish and the interruption is returned.
 type StreamAdopter interface {
-	AdoptStream(io.Reader) error
+	AdoptStream(context.Context, io.Reader) error
+}
+
+// Config is the configuration of the stream adopter.
+type Config struct {
+	// WaveSize is the number of hosts adopted before watching them, 0 disables the waves.
+	WaveSize int
+	// Watcher watches the adopted hosts of each wave, required with waves.
+	Watcher watch.Watcher
+	// Concurrency is the number of hosts adopted at the same time, the adoptions on the
+	// same hosted zone are serialized by the adopter. Defaults to 1.
+	Concurrency int
+	// Timeout is the maximum time of the adoption of each host, 0 disables it.
+	Timeout time.Duration
+	// Handlers get the result of every host in the same order as the stream, including
+	// the hosts skipped by the filter.
+	Handlers []ResultHandler
+}
+
+// ResultHandler handles the result of a host.
+type ResultHandler interface {
+	Handle(res *model.Result)
+}
+
+func (c *Config) defaults() {
+	if c.Concurrency <= 0 {
+		c.Concurrency = 1
+	}
 }
 
 type streamAdopter struct {
+	cfg    Config
 	adSvc  adopt.RSAdopter
 	flSvc  filter.EntryValidator
 	logger log.Logger
 }
 
 // NewStreamAdopter returns a new stream adopter.
-func NewStreamAdopter(adSvc adopt.RSAdopter, flSvc filter.EntryValidator, logger log.Logger) StreamAdopter {
+func NewStreamAdopter(cfg Config, adSvc adopt.RSAdopter, flSvc filter.EntryValidator, logger log.Logger) StreamAdopter {
+	cfg.defaults()
 	return &streamAdopter{
+		cfg:    cfg,
 		adSvc:  adSvc,
 		flSvc:  flSvc,
 		logger: logger,
 	}
 }
 
-func (s *streamAdopter) AdoptStream(r io.Reader) error {
+// host is a host of the stream, the entry is nil when the host is skipped by the filter.
+type host struct {
+	name  string
+	entry *model.Entry
+}
+
+// pending is a host dispatched to the adoption, done is closed once it has the result.
+type pending struct {
+	host   host
+	result *model.Result
+	err    error
+	done   chan struct{}
+}
+
+func (s *streamAdopter) AdoptStream(ctx context.Context, r io.Reader) error {
 	sc := bufio.NewScanner(r)
-	for sc.Scan() {
-		domain := sc.Text()
-		if domain != "" {
-			err := s.adoptEntry(domain)
-			if err != nil {
-				s.logger.Warningf("error adopting entry: %s", err)
+	next := func() (host, bool) {
+		return s.nextHost(ctx, sc)
+	}
+
+	// Without waves the hosts are adopted as they are read.
+	if s.cfg.WaveSize <= 0 {
+		s.adoptHosts(ctx, next)
+		if err := sc.Err(); err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+
+	for {
+		hosts := []host{}
+		entries := 0
+		for entries < s.cfg.WaveSize {
+			h, ok := next()
+			if !ok {
+				break
+			}
+			hosts = append(hosts, h)
+			if h.entry != nil {
+				entries++
 			}
 		}
 		if err := sc.Err(); err != nil {
-			return nil
+			return err
+		}
+		if len(hosts) == 0 {
+			return ctx.Err()
+		}
+		if err := s.adoptWave(ctx, hosts); err != nil {
+			return err
+		}
+		if entries < s.cfg.WaveSize {
+			return ctx.Err()
 		}
 	}
-	return nil
 }
 
-func (s *streamAdopter) adoptEntry(domain string) error {
-	entry, err := s.flSvc.Validate(domain)
-	if err != nil {
-		s.logger.Debugf("ignoring domain %s", domain)
+// nextHost reads the next host of the stream, it returns false at the end of the stream
+// or when the context is done. Each line has the host and optionally the ingress of the host.
+func (s *streamAdopter) nextHost(ctx context.Context, sc *bufio.Scanner) (host, bool) {
+	for ctx.Err() == nil && sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		entry, err := s.flSvc.Validate(ctx, fields[0])
+		if err != nil {
+			return host{name: fields[0]}, true
+		}
+		if len(fields) > 1 {
+			entry.Ingress = fields[1]
+		}
+		return host{name: fields[0], entry: entry}, true
+	}
+	return host{}, false
+}
+
+// adoptWave adopts the hosts of a wave and watches the adopted ones.
+func (s *streamAdopter) adoptWave(ctx context.Context, hosts []host) error {
+	i := 0
+	results := s.adoptHosts(ctx, func() (host, bool) {
+		if i >= len(hosts) {
+			return host{}, false
+		}
+		i++
+		return hosts[i-1], true
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	wave := []*model.Result{}
+	for _, res := range results {
+		if res != nil && res.Outcome.Success() {
+			wave = append(wave, res)
+		}
+	}
+	if len(wave) == 0 {
 		return nil
 	}
+	return s.watchWave(ctx, wave)
+}
 
-	err = s.adSvc.Adopt(entry)
+// adoptHosts adopts the hosts returned by next as they come with the configured concurrency,
+// the results are reported and returned in the same order as the hosts. The hosts read
+// ahead of the reported ones are bounded, so a slow adoption holds the reading. Once the
+// context is done no more hosts are read nor adopted, their results are nil.
+func (s *streamAdopter) adoptHosts(ctx context.Context, next func() (host, bool)) []*model.Result {
+	queue := make(chan *pending, 2*s.cfg.Concurrency)
+	work := make(chan *pending)
+
+	var wg sync.WaitGroup
+	for w := 0; w < s.cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range work {
+				// Interrupted while dispatching.
+				if ctx.Err() != nil {
+					close(p.done)
+					continue
+				}
+				actx, cancel := s.adoptContext(ctx)
+				p.result, p.err = s.adSvc.Adopt(actx, p.host.entry)
+				cancel()
+				close(p.done)
+			}
+		}()
+	}
+
+	// Dispatch the hosts as they are read.
+	go func() {
+		defer close(queue)
+		defer close(work)
+		for ctx.Err() == nil {
+			h, ok := next()
+			if !ok {
+				return
+			}
+			p := &pending{host: h, done: make(chan struct{})}
+			if h.entry == nil {
+				p.result = &model.Result{
+					Host:    h.name,
+					Outcome: model.OutcomeSkipped,
+					Action:  model.ActionNone,
+					Message: "not matching the filter",
+				}
+				close(p.done)
+			}
+			queue <- p
+			if h.entry == nil {
+				continue
+			}
+
+			select {
+			case work <- p:
+			case <-ctx.Done():
+				// Not adopted.
+				close(p.done)
+				return
+			}
+		}
+	}()
+
+	results := []*model.Result{}
+	for p := range queue {
+		<-p.done
+		s.report(p.result, p.err)
+		if p.result != nil {
+			for _, h := range s.cfg.Handlers {
+				h.Handle(p.result)
+			}
+		}
+		results = append(results, p.result)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// adoptContext returns the context of an adoption. It's not canceled when the stream is
+// interrupted so the in-flight change batches finish, but it keeps the deadline of the
+// stream and the timeout of the adoption.
+func (s *streamAdopter) adoptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	actx := detachedContext{parent: ctx}
+	if dl, ok := ctx.Deadline(); ok {
+		if s.cfg.Timeout > 0 && time.Now().Add(s.cfg.Timeout).Before(dl) {
+			dl = time.Now().Add(s.cfg.Timeout)
+		}
+		return context.WithDeadline(actx, dl)
+	}
+	if s.cfg.Timeout > 0 {
+		return context.WithTimeout(actx, s.cfg.Timeout)
+	}
+	return context.WithCancel(actx)
+}
+
+// detachedContext has the values of the parent context but it's never canceled nor
+// has a deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// watchWave watches the adopted hosts of a wave, if any of the record sets is deleted
+// the adoption stops.
+func (s *streamAdopter) watchWave(ctx context.Context, wave []*model.Result) error {
+	s.logger.Infof("wave of %d hosts adopted, watching them", len(wave))
+	report, err := s.cfg.Watcher.Watch(ctx, wave)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	if n := report.Deletions(); n > 0 {
+		return fmt.Errorf("%d record sets deleted after the adoption, stopping", n)
+	}
+	s.logger.Infof("wave watched with %d changes", len(report.Changes))
+	return ctx.Err()
+}
+
+// report logs the result of an adoption.
+func (s *streamAdopter) report(res *model.Result, err error) {
+	if err != nil {
+		s.logger.Warningf("error adopting entry: %s", err)
+		return
+	}
+	if res == nil {
+		return
+	}
+
+	logger := s.logger.With("host", res.Host).With("outcome", res.Outcome)
+	switch res.Outcome {
+	case model.OutcomeSkipped:
+		logger.Debugf("ignoring domain")
+	case model.OutcomeAdopted, model.OutcomeAlreadyOwned:
+		logger.Debugf("entry adopted")
+	case model.OutcomeOwnerConflict:
+		logger.Warningf("ownership conflict, needs manual review: %s", res.Message)
+	default:
+		logger.Warningf("entry not adopted: %s", res.Message)
+	}
 }
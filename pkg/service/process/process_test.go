@@ -2,7 +2,13 @@ package process_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -10,7 +16,10 @@ import (
 	"github.com/slok/external-dns-aws-migrator/pkg/log"
 	madopt "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/adopt"
 	mfilter "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/filter"
+	mwatch "github.com/slok/external-dns-aws-migrator/pkg/mocks/service/watch"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
 	"github.com/slok/external-dns-aws-migrator/pkg/service/process"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/watch"
 )
 
 func TestAdoptStream(t *testing.T) {
@@ -40,12 +49,12 @@ wolverine.marvel.comic.io
 			mf := &mfilter.EntryValidator{}
 			ma := &madopt.RSAdopter{}
 
-			mf.On("Validate", mock.Anything).Times(test.expTimeCalls).Return(nil, nil)
-			ma.On("Adopt", mock.Anything).Times(test.expTimeCalls).Return(nil)
+			mf.On("Validate", mock.Anything, mock.Anything).Times(test.expTimeCalls).Return(&model.Entry{}, nil)
+			ma.On("Adopt", mock.Anything, mock.Anything).Times(test.expTimeCalls).Return(&model.Result{Outcome: model.OutcomeAdopted}, nil)
 
-			sa := process.NewStreamAdopter(ma, mf, log.Dummy)
+			sa := process.NewStreamAdopter(process.Config{}, ma, mf, log.Dummy)
 			bs := bytes.NewBufferString(test.entries)
-			err := sa.AdoptStream(bs)
+			err := sa.AdoptStream(context.Background(), bs)
 			if assert.NoError(err) {
 				mf.AssertExpectations(t)
 				ma.AssertExpectations(t)
@@ -53,3 +62,240 @@ wolverine.marvel.comic.io
 		})
 	}
 }
+
+func TestAdoptStreamWaves(t *testing.T) {
+	tests := []struct {
+		name          string
+		waveSize      int
+		report        *watch.Report
+		expAdoptCalls int
+		expWaveSizes  []int
+		expErr        bool
+	}{
+		{
+			name:          "Adopting in waves should watch each wave.",
+			waveSize:      2,
+			report:        &watch.Report{},
+			expAdoptCalls: 5,
+			expWaveSizes:  []int{2, 2, 1},
+		},
+		{
+			name:     "Adopting in waves should stop when the watch detects deletions.",
+			waveSize: 2,
+			report: &watch.Report{Changes: []watch.Change{
+				{Host: "batman.dc.comic.io", RecordSet: "A batman.dc.comic.io", Kind: watch.KindDeleted},
+			}},
+			expAdoptCalls: 2,
+			expWaveSizes:  []int{2},
+			expErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks
+			mf := &mfilter.EntryValidator{}
+			ma := &madopt.RSAdopter{}
+			mw := &mwatch.Watcher{}
+
+			mf.On("Validate", mock.Anything, mock.Anything).Return(&model.Entry{}, nil)
+			ma.On("Adopt", mock.Anything, mock.Anything).Return(&model.Result{Outcome: model.OutcomeAdopted}, nil)
+			gotWaveSizes := []int{}
+			mw.On("Watch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				gotWaveSizes = append(gotWaveSizes, len(args.Get(1).([]*model.Result)))
+			}).Return(test.report, nil)
+
+			sa := process.NewStreamAdopter(process.Config{WaveSize: test.waveSize, Watcher: mw}, ma, mf, log.Dummy)
+			bs := bytes.NewBufferString(`
+batman.dc.comic.io
+superman.dc.comic.io
+deadpool.marvel.comic.io
+spiderman.marvel.comic.io
+wolverine.marvel.comic.io
+`)
+			err := sa.AdoptStream(context.Background(), bs)
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			ma.AssertNumberOfCalls(t, "Adopt", test.expAdoptCalls)
+			assert.Equal(test.expWaveSizes, gotWaveSizes)
+		})
+	}
+}
+
+func TestAdoptStreamConcurrency(t *testing.T) {
+	hosts := []string{
+		"batman.dc.comic.io",
+		"superman.dc.comic.io",
+		"deadpool.marvel.comic.io",
+		"spiderman.marvel.comic.io",
+		"wolverine.marvel.comic.io",
+		"hulk.marvel.comic.io",
+	}
+
+	tests := []struct {
+		name           string
+		concurrency    int
+		expMaxInFlight int
+	}{
+		{
+			name:           "Adopting without concurrency should adopt one host at a time.",
+			concurrency:    0,
+			expMaxInFlight: 1,
+		},
+		{
+			name:           "Adopting with concurrency should adopt up to the concurrency limit at the same time.",
+			concurrency:    3,
+			expMaxInFlight: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks
+			mf := &mfilter.EntryValidator{}
+			ma := &madopt.RSAdopter{}
+			mw := &mwatch.Watcher{}
+
+			var mu sync.Mutex
+			inFlight, maxInFlight := 0, 0
+			for i, host := range hosts {
+				host := host
+				// The first hosts take longer so they finish last.
+				delay := time.Duration(len(hosts)-i) * 5 * time.Millisecond
+				mf.On("Validate", mock.Anything, host).Return(&model.Entry{Host: host}, nil)
+				ma.On("Adopt", mock.Anything, &model.Entry{Host: host}).Run(func(mock.Arguments) {
+					mu.Lock()
+					inFlight++
+					if inFlight > maxInFlight {
+						maxInFlight = inFlight
+					}
+					mu.Unlock()
+					time.Sleep(delay)
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+				}).Return(&model.Result{Host: host, Outcome: model.OutcomeAdopted}, nil)
+			}
+			gotHosts := []string{}
+			mw.On("Watch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				for _, res := range args.Get(1).([]*model.Result) {
+					gotHosts = append(gotHosts, res.Host)
+				}
+			}).Return(&watch.Report{}, nil)
+
+			cfg := process.Config{Concurrency: test.concurrency, WaveSize: len(hosts), Watcher: mw}
+			sa := process.NewStreamAdopter(cfg, ma, mf, log.Dummy)
+			bs := bytes.NewBufferString(strings.Join(hosts, "\n"))
+			if assert.NoError(sa.AdoptStream(context.Background(), bs)) {
+				ma.AssertExpectations(t)
+				assert.Equal(test.expMaxInFlight, maxInFlight)
+				assert.Equal(hosts, gotHosts)
+			}
+		})
+	}
+}
+
+func TestAdoptStreamInterrupted(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks
+	mf := &mfilter.EntryValidator{}
+	ma := &madopt.RSAdopter{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var adoptErr error
+	mf.On("Validate", mock.Anything, mock.Anything).Return(&model.Entry{}, nil)
+	ma.On("Adopt", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		// Interrupted in the middle of the adoption.
+		cancel()
+		adoptErr = args.Get(0).(context.Context).Err()
+	}).Return(&model.Result{Outcome: model.OutcomeAdopted}, nil)
+
+	sa := process.NewStreamAdopter(process.Config{}, ma, mf, log.Dummy)
+	bs := bytes.NewBufferString("batman.dc.comic.io\nsuperman.dc.comic.io\n")
+	err := sa.AdoptStream(ctx, bs)
+
+	// The in-flight adoption finishes and the rest are not adopted.
+	assert.Equal(context.Canceled, err)
+	assert.NoError(adoptErr)
+	ma.AssertNumberOfCalls(t, "Adopt", 1)
+}
+
+type resultHandler struct {
+	results []*model.Result
+}
+
+func (r *resultHandler) Handle(res *model.Result) {
+	r.results = append(r.results, res)
+}
+
+func TestAdoptStreamHandlers(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks
+	mf := &mfilter.EntryValidator{}
+	ma := &madopt.RSAdopter{}
+
+	mf.On("Validate", mock.Anything, "batman.dc.comic.io").Return(&model.Entry{Host: "batman.dc.comic.io"}, nil)
+	mf.On("Validate", mock.Anything, "deadpool.marvel.comic.io").Return(nil, errors.New("wanted error"))
+	mf.On("Validate", mock.Anything, "superman.dc.comic.io").Return(&model.Entry{Host: "superman.dc.comic.io"}, nil)
+	ma.On("Adopt", mock.Anything, &model.Entry{Host: "batman.dc.comic.io"}).Return(&model.Result{Host: "batman.dc.comic.io", Outcome: model.OutcomeAdopted}, nil)
+	ma.On("Adopt", mock.Anything, &model.Entry{Host: "superman.dc.comic.io"}).Return(&model.Result{Host: "superman.dc.comic.io", Outcome: model.OutcomeOwnerConflict}, nil)
+
+	h := &resultHandler{}
+	sa := process.NewStreamAdopter(process.Config{Handlers: []process.ResultHandler{h}}, ma, mf, log.Dummy)
+	bs := bytes.NewBufferString("batman.dc.comic.io\ndeadpool.marvel.comic.io\nsuperman.dc.comic.io\n")
+	if assert.NoError(sa.AdoptStream(context.Background(), bs)) {
+		exp := []*model.Result{
+			{Host: "batman.dc.comic.io", Outcome: model.OutcomeAdopted},
+			{Host: "deadpool.marvel.comic.io", Outcome: model.OutcomeSkipped, Action: model.ActionNone, Message: "not matching the filter"},
+			{Host: "superman.dc.comic.io", Outcome: model.OutcomeOwnerConflict},
+		}
+		assert.Equal(exp, h.results)
+	}
+}
+
+func TestAdoptStreamReadingHosts(t *testing.T) {
+	assert := assert.New(t)
+
+	// Mocks
+	mf := &mfilter.EntryValidator{}
+	ma := &madopt.RSAdopter{}
+
+	adopted := make(chan string, 2)
+	mf.On("Validate", mock.Anything, mock.Anything).Return(func(_ context.Context, host string) *model.Entry {
+		return &model.Entry{Host: host}
+	}, nil)
+	ma.On("Adopt", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		adopted <- args.Get(1).(*model.Entry).Host
+	}).Return(&model.Result{Outcome: model.OutcomeAdopted}, nil)
+
+	pr, pw := io.Pipe()
+	sa := process.NewStreamAdopter(process.Config{}, ma, mf, log.Dummy)
+	errC := make(chan error)
+	go func() {
+		errC <- sa.AdoptStream(context.Background(), pr)
+	}()
+
+	// The host is adopted before the end of the stream.
+	pw.Write([]byte("batman.dc.comic.io\n"))
+	select {
+	case host := <-adopted:
+		assert.Equal("batman.dc.comic.io", host)
+	case <-time.After(5 * time.Second):
+		assert.Fail("the host wasn't adopted before the end of the stream")
+	}
+
+	pw.Write([]byte("superman.dc.comic.io\n"))
+	pw.Close()
+	assert.NoError(<-errC)
+	assert.Equal("superman.dc.comic.io", <-adopted)
+}
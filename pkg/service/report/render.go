@@ -0,0 +1,171 @@
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Format is the format of the report.
+type Format string
+
+// Formats.
+const (
+	MarkdownFormat Format = "markdown"
+	HTMLFormat     Format = "html"
+)
+
+// WriteReport writes the report in the required format.
+func WriteReport(w io.Writer, format Format, r *Report) error {
+	switch format {
+	case MarkdownFormat:
+		return markdownTmpl.Execute(w, r)
+	case HTMLFormat:
+		return htmlTmpl.Execute(w, r)
+	}
+
+	return fmt.Errorf("invalid report format %q", format)
+}
+
+func zoneName(name string) string {
+	if name == "" {
+		return "Without hosted zone"
+	}
+	return name
+}
+
+var funcs = map[string]interface{}{
+	"zone": zoneName,
+	"md": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+	},
+	"join": strings.Join,
+	"time": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+}
+
+var markdownTmpl = texttemplate.Must(texttemplate.New("markdown").Funcs(funcs).Parse(`# Adoption report
+
+- Owner ID: ` + "`{{ .OwnerID }}`" + `
+- Source: {{ .Source }}
+
+## Owners
+
+| Owner | Adopted | Conflicts |
+| --- | --- | --- |
+{{- range .Owners }}
+| {{ md .ID }} | {{ .Adopted }} | {{ .Conflicts }} |
+{{- end }}
+{{ range .Zones }}
+## {{ zone .Name }}
+{{ if .Adopted }}
+### Adopted
+
+| Host | Owner | Outcome | Action | TXT records | Change ID |
+| --- | --- | --- | --- | --- | --- |
+{{- range .Adopted }}
+| {{ md .Host }} | {{ md .Owner }} | {{ .Outcome }}{{ if .DryRun }} (dry-run){{ end }} | {{ .Action }} | {{ range $i, $t := .TXTs }}{{ if $i }}<br>{{ end }}{{ md $t.Name }} ` + "`{{ md $t.Value }}`" + `{{ end }} | {{ .ChangeID }} |
+{{- end }}
+{{ end }}
+{{- if .Conflicts }}
+### Conflicts
+
+| Host | Owner | Reason |
+| --- | --- | --- |
+{{- range .Conflicts }}
+| {{ md .Host }} | {{ md .Owner }} | {{ md .Reason }} |
+{{- end }}
+{{ end }}
+{{- if .NotAdopted }}
+### Not adopted
+
+| Host | Outcome | Reason |
+| --- | --- | --- |
+{{- range .NotAdopted }}
+| {{ md .Host }} | {{ .Outcome }} | {{ md .Reason }} |
+{{- end }}
+{{ end }}
+{{- end }}
+{{- if .Drift }}
+## Drift
+
+| Time | Zone | Host | Record set | Kind | Details |
+| --- | --- | --- | --- | --- | --- |
+{{- range .Drift }}
+| {{ time .Time }} | {{ md .Zone }} | {{ md .Host }} | {{ md .RecordSet }} | {{ .Kind }} | {{ md (join .Details "<br>") }} |
+{{- end }}
+{{ end -}}
+`))
+
+var htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Adoption report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Adoption report</h1>
+<ul>
+<li>Owner ID: <code>{{ .OwnerID }}</code></li>
+<li>Source: {{ .Source }}</li>
+</ul>
+<h2>Owners</h2>
+<table>
+<tr><th>Owner</th><th>Adopted</th><th>Conflicts</th></tr>
+{{- range .Owners }}
+<tr><td>{{ .ID }}</td><td>{{ .Adopted }}</td><td>{{ .Conflicts }}</td></tr>
+{{- end }}
+</table>
+{{- range .Zones }}
+<h2>{{ zone .Name }}</h2>
+{{- if .Adopted }}
+<h3>Adopted</h3>
+<table>
+<tr><th>Host</th><th>Owner</th><th>Outcome</th><th>Action</th><th>TXT records</th><th>Change ID</th></tr>
+{{- range .Adopted }}
+<tr><td>{{ .Host }}</td><td>{{ .Owner }}</td><td>{{ .Outcome }}{{ if .DryRun }} (dry-run){{ end }}</td><td>{{ .Action }}</td><td>{{ range $i, $t := .TXTs }}{{ if $i }}<br>{{ end }}{{ $t.Name }} <code>{{ $t.Value }}</code>{{ end }}</td><td>{{ .ChangeID }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Conflicts }}
+<h3>Conflicts</h3>
+<table>
+<tr><th>Host</th><th>Owner</th><th>Reason</th></tr>
+{{- range .Conflicts }}
+<tr><td>{{ .Host }}</td><td>{{ .Owner }}</td><td>{{ .Reason }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .NotAdopted }}
+<h3>Not adopted</h3>
+<table>
+<tr><th>Host</th><th>Outcome</th><th>Reason</th></tr>
+{{- range .NotAdopted }}
+<tr><td>{{ .Host }}</td><td>{{ .Outcome }}</td><td>{{ .Reason }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- end }}
+{{- if .Drift }}
+<h2>Drift</h2>
+<table>
+<tr><th>Time</th><th>Zone</th><th>Host</th><th>Record set</th><th>Kind</th><th>Details</th></tr>
+{{- range .Drift }}
+<tr><td>{{ time .Time }}</td><td>{{ .Zone }}</td><td>{{ .Host }}</td><td>{{ .RecordSet }}</td><td>{{ .Kind }}</td><td>{{ range $i, $d := .Details }}{{ if $i }}<br>{{ end }}{{ $d }}{{ end }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
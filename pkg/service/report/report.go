@@ -0,0 +1,321 @@
+package report
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/plan"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/watch"
+)
+
+// Host is a host on the report.
+type Host struct {
+	Host     string
+	Owner    string
+	Outcome  model.Outcome
+	Action   model.Action
+	TXTs     []model.TXT
+	ChangeID string
+	Reason   string
+	DryRun   bool
+}
+
+// Zone are the hosts of a hosted zone grouped by outcome, sorted by owner and host.
+type Zone struct {
+	// Name is the hosted zone name, empty for the hosts without zone.
+	Name string
+	// Adopted are the hosts owned after the adoption.
+	Adopted []Host
+	// Conflicts are the hosts owned by other owner IDs.
+	Conflicts []Host
+	// NotAdopted are the rest of the hosts (skipped by the filter, missing records...).
+	NotAdopted []Host
+}
+
+// Owner are the counts of the hosts of an owner ID.
+type Owner struct {
+	ID        string
+	Adopted   int
+	Conflicts int
+}
+
+// Drift is a change on the adopted record sets after the adoption.
+type Drift struct {
+	Time      time.Time
+	Zone      string
+	Host      string
+	RecordSet string
+	Kind      watch.Kind
+	Details   []string
+}
+
+// Report is the report of an adoption.
+type Report struct {
+	OwnerID string
+	// Source is where the results come from (run, plan or journal).
+	Source string
+	Owners []*Owner
+	Zones  []*Zone
+	Drift  []Drift
+}
+
+// Sources.
+const (
+	SourceRun     = "run"
+	SourcePlan    = "plan"
+	SourceJournal = "journal"
+)
+
+// Builder builds the report of an adoption from the results of the hosts.
+type Builder interface {
+	// Handle adds the result of a host, the last result of a host wins.
+	Handle(res *model.Result)
+	// AddDrift adds the changes detected on the adopted record sets.
+	AddDrift(changes []watch.Change)
+	// Report returns the report.
+	Report() *Report
+}
+
+type builder struct {
+	ownerID string
+	source  string
+
+	mu      sync.Mutex
+	results map[string]*model.Result
+	drift   []Drift
+}
+
+// NewBuilder returns a new Builder.
+func NewBuilder(ownerID, source string) Builder {
+	return &builder{
+		ownerID: ownerID,
+		source:  source,
+		results: map[string]*model.Result{},
+	}
+}
+
+func (b *builder) Handle(res *model.Result) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.results[strings.TrimSuffix(res.Host, ".")] = res
+}
+
+func (b *builder) AddDrift(changes []watch.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range changes {
+		b.drift = append(b.drift, Drift{
+			Time:      ch.Time,
+			Zone:      ch.Zone,
+			Host:      ch.Host,
+			RecordSet: ch.RecordSet,
+			Kind:      ch.Kind,
+			Details:   ch.Details,
+		})
+	}
+}
+
+func (b *builder) Report() *Report {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	zones := map[string]*Zone{}
+	owners := map[string]*Owner{}
+	for _, res := range b.results {
+		z, ok := zones[res.Zone]
+		if !ok {
+			z = &Zone{Name: res.Zone}
+			zones[res.Zone] = z
+		}
+
+		h := Host{
+			Host:     res.Host,
+			Owner:    res.Owner,
+			Outcome:  res.Outcome,
+			Action:   res.Action,
+			TXTs:     res.TXTs,
+			ChangeID: res.ChangeID,
+			Reason:   res.Message,
+			DryRun:   res.DryRun,
+		}
+		switch {
+		case res.Outcome.Success():
+			h.Owner = b.adoptedOwner(res)
+			z.Adopted = append(z.Adopted, h)
+		case res.Outcome == model.OutcomeOwnerConflict:
+			z.Conflicts = append(z.Conflicts, h)
+		default:
+			z.NotAdopted = append(z.NotAdopted, h)
+			continue
+		}
+
+		o, ok := owners[h.Owner]
+		if !ok {
+			o = &Owner{ID: h.Owner}
+			owners[h.Owner] = o
+		}
+		if res.Outcome.Success() {
+			o.Adopted++
+		} else {
+			o.Conflicts++
+		}
+	}
+
+	r := &Report{
+		OwnerID: b.ownerID,
+		Source:  b.source,
+		Owners:  []*Owner{},
+		Zones:   []*Zone{},
+		Drift:   append([]Drift{}, b.drift...),
+	}
+	for _, o := range owners {
+		r.Owners = append(r.Owners, o)
+	}
+	sort.Slice(r.Owners, func(i, j int) bool { return r.Owners[i].ID < r.Owners[j].ID })
+
+	for _, z := range zones {
+		r.Zones = append(r.Zones, z)
+	}
+	sortZones(r.Zones)
+
+	return r
+}
+
+// Combine combines the reports of multiple jobs with different owner IDs in a single
+// report.
+func Combine(reports ...*Report) *Report {
+	r := &Report{
+		Owners: []*Owner{},
+		Zones:  []*Zone{},
+		Drift:  []Drift{},
+	}
+	ownerIDs := []string{}
+	owners := map[string]*Owner{}
+	zones := map[string]*Zone{}
+	for _, rp := range reports {
+		ownerIDs = append(ownerIDs, rp.OwnerID)
+		r.Source = rp.Source
+		for _, o := range rp.Owners {
+			ro, ok := owners[o.ID]
+			if !ok {
+				ro = &Owner{ID: o.ID}
+				owners[o.ID] = ro
+				r.Owners = append(r.Owners, ro)
+			}
+			ro.Adopted += o.Adopted
+			ro.Conflicts += o.Conflicts
+		}
+		for _, z := range rp.Zones {
+			rz, ok := zones[z.Name]
+			if !ok {
+				rz = &Zone{Name: z.Name}
+				zones[z.Name] = rz
+				r.Zones = append(r.Zones, rz)
+			}
+			rz.Adopted = append(rz.Adopted, z.Adopted...)
+			rz.Conflicts = append(rz.Conflicts, z.Conflicts...)
+			rz.NotAdopted = append(rz.NotAdopted, z.NotAdopted...)
+		}
+		r.Drift = append(r.Drift, rp.Drift...)
+	}
+	r.OwnerID = strings.Join(ownerIDs, ", ")
+	sort.Slice(r.Owners, func(i, j int) bool { return r.Owners[i].ID < r.Owners[j].ID })
+	sortZones(r.Zones)
+	sort.SliceStable(r.Drift, func(i, j int) bool { return r.Drift[i].Time.Before(r.Drift[j].Time) })
+
+	return r
+}
+
+// sortZones sorts the zones by name and their hosts, the hosts without zone go last.
+func sortZones(zones []*Zone) {
+	for _, z := range zones {
+		sortHosts(z.Adopted)
+		sortHosts(z.Conflicts)
+		sortHosts(z.NotAdopted)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].Name == "" || zones[j].Name == "" {
+			return zones[j].Name == ""
+		}
+		return zones[i].Name < zones[j].Name
+	})
+}
+
+// adoptedOwner returns the owner ID of the ownership TXT values written, the owner ID
+// of the report otherwise.
+func (b *builder) adoptedOwner(res *model.Result) string {
+	for _, txt := range res.TXTs {
+		if labels, err := registry.ParseTXT(txt.Value); err == nil {
+			return labels.Owner()
+		}
+	}
+	return b.ownerID
+}
+
+func sortHosts(hosts []Host) {
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Owner != hosts[j].Owner {
+			return hosts[i].Owner < hosts[j].Owner
+		}
+		return hosts[i].Host < hosts[j].Host
+	})
+}
+
+type watcher struct {
+	w watch.Watcher
+	b Builder
+}
+
+// NewWatcher returns a watcher that adds the changes detected by the watcher to the report.
+func NewWatcher(w watch.Watcher, b Builder) watch.Watcher {
+	return &watcher{w: w, b: b}
+}
+
+func (w *watcher) Watch(ctx context.Context, results []*model.Result) (*watch.Report, error) {
+	report, err := w.w.Watch(ctx, results)
+	if report != nil {
+		w.b.AddDrift(report.Changes)
+	}
+	return report, err
+}
+
+// PlanResults returns the results of the hosts of a plan as if they were adopted.
+func PlanResults(p *plan.Plan) []*model.Result {
+	results := []*model.Result{}
+	for _, z := range p.Zones {
+		for _, h := range z.Hosts {
+			res := &model.Result{
+				Host:    h.Host,
+				Zone:    z.Name,
+				ZoneID:  z.ID,
+				Outcome: model.OutcomeAlreadyOwned,
+				Action:  model.ActionNone,
+				Rule:    h.Rule,
+				DryRun:  true,
+			}
+			for _, ch := range h.Changes {
+				if ch.RecordSet.Type != string(route53.RRTypeTxt) || len(ch.RecordSet.Values) == 0 {
+					continue
+				}
+				res.Outcome = model.OutcomeAdopted
+				res.Action = model.ActionCreate
+				if ch.Action == string(route53.ChangeActionUpsert) {
+					res.Action = model.ActionMerge
+				}
+				// The ownership value goes first on the merged TXT record sets.
+				res.TXTs = append(res.TXTs, model.TXT{Name: ch.RecordSet.Name, Value: ch.RecordSet.Values[0]})
+			}
+			results = append(results, res)
+		}
+	}
+	return results
+}
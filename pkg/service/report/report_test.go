@@ -0,0 +1,159 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/plan"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/report"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/watch"
+)
+
+const batmanTXT = `"heritage=external-dns,external-dns/owner=batman"`
+
+func testBuilder() report.Builder {
+	b := report.NewBuilder("batman", report.SourceRun)
+	b.Handle(&model.Result{Host: "robin.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeError, Message: "wanted error"})
+	// The last result of a host wins.
+	b.Handle(&model.Result{Host: "robin.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeAdopted, Action: model.ActionCreate,
+		TXTs: []model.TXT{{Name: "robin.dc.comic.io", Value: batmanTXT}}, ChangeID: "/change/2"})
+	b.Handle(&model.Result{Host: "batman.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeAlreadyOwned, Action: model.ActionNone})
+	b.Handle(&model.Result{Host: "joker.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeOwnerConflict, Owner: "joker", Message: "owned by joker"})
+	b.Handle(&model.Result{Host: "deadpool.marvel.comic.io", Outcome: model.OutcomeSkipped, Message: "not matching the filter"})
+	b.AddDrift([]watch.Change{{
+		Time:      time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+		Zone:      "dc.comic.io",
+		Host:      "robin.dc.comic.io",
+		RecordSet: "A robin.dc.comic.io",
+		Kind:      watch.KindChanged,
+		Details:   []string{"ttl: 60 -> 300"},
+	}})
+	return b
+}
+
+func TestBuilderReport(t *testing.T) {
+	assert := assert.New(t)
+
+	r := testBuilder().Report()
+
+	assert.Equal([]*report.Owner{{ID: "batman", Adopted: 2}, {ID: "joker", Conflicts: 1}}, r.Owners)
+	if assert.Len(r.Zones, 2) {
+		z := r.Zones[0]
+		assert.Equal("dc.comic.io", z.Name)
+		if assert.Len(z.Adopted, 2) {
+			assert.Equal("batman.dc.comic.io", z.Adopted[0].Host)
+			assert.Equal("robin.dc.comic.io", z.Adopted[1].Host)
+			assert.Equal("batman", z.Adopted[1].Owner)
+		}
+		assert.Len(z.Conflicts, 1)
+		assert.Len(z.NotAdopted, 0)
+
+		z = r.Zones[1]
+		assert.Equal("", z.Name)
+		assert.Len(z.NotAdopted, 1)
+	}
+	assert.Len(r.Drift, 1)
+}
+
+func TestCombine(t *testing.T) {
+	assert := assert.New(t)
+
+	b := report.NewBuilder("deadpool", report.SourceRun)
+	b.Handle(&model.Result{Host: "deadpool.marvel.comic.io", Zone: "marvel.comic.io", Outcome: model.OutcomeAdopted})
+	b.Handle(&model.Result{Host: "alfred.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeMissingRecord})
+	r := report.Combine(testBuilder().Report(), b.Report())
+
+	assert.Equal("batman, deadpool", r.OwnerID)
+	assert.Equal(report.SourceRun, r.Source)
+	assert.Equal([]*report.Owner{{ID: "batman", Adopted: 2}, {ID: "deadpool", Adopted: 1}, {ID: "joker", Conflicts: 1}}, r.Owners)
+	if assert.Len(r.Zones, 3) {
+		assert.Equal("dc.comic.io", r.Zones[0].Name)
+		assert.Len(r.Zones[0].Adopted, 2)
+		assert.Len(r.Zones[0].NotAdopted, 1)
+		assert.Equal("marvel.comic.io", r.Zones[1].Name)
+		assert.Equal("", r.Zones[2].Name)
+	}
+	assert.Len(r.Drift, 1)
+}
+
+func TestWriteReportMarkdown(t *testing.T) {
+	assert := assert.New(t)
+
+	var b bytes.Buffer
+	err := report.WriteReport(&b, report.MarkdownFormat, testBuilder().Report())
+	exp := "# Adoption report\n\n" +
+		"- Owner ID: `batman`\n" +
+		"- Source: run\n\n" +
+		"## Owners\n\n" +
+		"| Owner | Adopted | Conflicts |\n" +
+		"| --- | --- | --- |\n" +
+		"| batman | 2 | 0 |\n" +
+		"| joker | 0 | 1 |\n\n" +
+		"## dc.comic.io\n\n" +
+		"### Adopted\n\n" +
+		"| Host | Owner | Outcome | Action | TXT records | Change ID |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| batman.dc.comic.io | batman | already-owned | none |  |  |\n" +
+		"| robin.dc.comic.io | batman | adopted | create | robin.dc.comic.io `\"heritage=external-dns,external-dns/owner=batman\"` | /change/2 |\n\n" +
+		"### Conflicts\n\n" +
+		"| Host | Owner | Reason |\n" +
+		"| --- | --- | --- |\n" +
+		"| joker.dc.comic.io | joker | owned by joker |\n\n" +
+		"## Without hosted zone\n\n" +
+		"### Not adopted\n\n" +
+		"| Host | Outcome | Reason |\n" +
+		"| --- | --- | --- |\n" +
+		"| deadpool.marvel.comic.io | skipped | not matching the filter |\n\n" +
+		"## Drift\n\n" +
+		"| Time | Zone | Host | Record set | Kind | Details |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| 2019-01-02 03:04:05 | dc.comic.io | robin.dc.comic.io | A robin.dc.comic.io | changed | ttl: 60 -> 300 |\n"
+	if assert.NoError(err) {
+		assert.Equal(exp, b.String())
+	}
+}
+
+func TestWriteReportHTML(t *testing.T) {
+	assert := assert.New(t)
+
+	var b bytes.Buffer
+	err := report.WriteReport(&b, report.HTMLFormat, testBuilder().Report())
+	if assert.NoError(err) {
+		assert.Contains(b.String(), "<h2>dc.comic.io</h2>")
+		assert.Contains(b.String(), "<td>robin.dc.comic.io <code>&#34;heritage=external-dns,external-dns/owner=batman&#34;</code></td>")
+		assert.Contains(b.String(), "<td>ttl: 60 -&gt; 300</td>")
+	}
+}
+
+func TestPlanResults(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &plan.Plan{
+		Version: plan.Version,
+		OwnerID: "batman",
+		Zones: []*plan.Zone{{
+			ID:   "/hostedzone/gotham",
+			Name: "dc.comic.io",
+			Hosts: []*plan.Host{
+				{Host: "batman.dc.comic.io", Changes: []plan.Change{
+					{Action: "CREATE", RecordSet: plan.RecordSet{Name: "batman.dc.comic.io", Type: "TXT", Values: []string{batmanTXT}}},
+				}},
+				{Host: "robin.dc.comic.io", Changes: []plan.Change{
+					{Action: "UPSERT", RecordSet: plan.RecordSet{Name: "robin.dc.comic.io", Type: "TXT", Values: []string{batmanTXT, `"v=spf1 -all"`}}},
+				}},
+			},
+		}},
+	}
+
+	exp := []*model.Result{
+		{Host: "batman.dc.comic.io", Zone: "dc.comic.io", ZoneID: "/hostedzone/gotham", Outcome: model.OutcomeAdopted, Action: model.ActionCreate, DryRun: true,
+			TXTs: []model.TXT{{Name: "batman.dc.comic.io", Value: batmanTXT}}},
+		{Host: "robin.dc.comic.io", Zone: "dc.comic.io", ZoneID: "/hostedzone/gotham", Outcome: model.OutcomeAdopted, Action: model.ActionMerge, DryRun: true,
+			TXTs: []model.TXT{{Name: "robin.dc.comic.io", Value: batmanTXT}}},
+	}
+	assert.Equal(exp, report.PlanResults(p))
+}
@@ -0,0 +1,343 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+)
+
+// Config is the configuration of the rollbacker.
+type Config struct {
+	// DryRun will not make any change on Route53.
+	DryRun bool
+	// Naming is the naming of the external-dns TXT registry records.
+	Naming registry.Naming
+}
+
+// Rollbacker knows how to remove the ownership TXT records created by the adoption.
+type Rollbacker interface {
+	// Find returns the entries that have the ownership TXT record that the adoption
+	// would create for the hosts that are valid for the validator.
+	Find(ctx context.Context, validator filter.EntryValidator) ([]*model.Entry, error)
+	// Rollback deletes the ownership TXT records of the entries, only the ones
+	// that still have the same value that the adoption wrote. The TXT records where
+	// the ownership was merged only get the ownership value removed.
+	Rollback(ctx context.Context, entries []*model.Entry) error
+	// RollbackResults deletes exactly the ownership TXT record sets written by the adoption
+	// results (the ones of a journal), only the ones that still have the written value. The
+	// TXT records where the ownership was merged only get the ownership value removed.
+	RollbackResults(ctx context.Context, results []*model.Result) error
+}
+
+type rollbacker struct {
+	cfg       Config
+	r53Svc    route53iface.Route53API
+	zones     adopt.ZoneIndex
+	rrsGetter adopt.RecordSetGetter
+	logger    log.Logger
+}
+
+// NewRollbacker returns a new Rollbacker.
+func NewRollbacker(cfg Config, r53Svc route53iface.Route53API, logger log.Logger) Rollbacker {
+	return &rollbacker{
+		cfg:       cfg,
+		r53Svc:    r53Svc,
+		zones:     adopt.NewZoneIndex(r53Svc),
+		rrsGetter: adopt.NewRecordSetGetter(r53Svc),
+		logger:    logger,
+	}
+}
+
+func (r *rollbacker) Find(ctx context.Context, validator filter.EntryValidator) ([]*model.Entry, error) {
+	zones, err := r.zones.Zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*model.Entry{}
+	seen := map[string]bool{}
+	for _, hz := range zones {
+		rrs, err := r.rrsGetter.ListRecordSets(ctx, aws.StringValue(hz.Id))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rs := range rrs {
+			if rs.Type != route53.RRTypeTxt {
+				continue
+			}
+			host, ok := r.cfg.Naming.Host(strings.TrimSuffix(aws.StringValue(rs.Name), "."))
+			if !ok || seen[host] {
+				continue
+			}
+			entry, err := validator.Validate(ctx, host)
+			if err != nil {
+				continue
+			}
+			if isAdoptionTXT(rs, entry) {
+				seen[host] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *rollbacker) Rollback(ctx context.Context, entries []*model.Entry) error {
+	// Group by hosted zone so the deletes are batched per zone.
+	zoneEntries := map[string][]*model.Entry{}
+	for _, entry := range entries {
+		hz, err := r.zones.Find(ctx, entry.Host)
+		if err != nil {
+			r.logger.With("host", entry.Host).Warningf("can't rollback: %s", err)
+			continue
+		}
+		id := aws.StringValue(hz.Id)
+		zoneEntries[id] = append(zoneEntries[id], entry)
+	}
+
+	for hzID, entries := range zoneEntries {
+		err := r.rollbackZone(ctx, hzID, entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *rollbacker) rollbackZone(ctx context.Context, hzID string, entries []*model.Entry) error {
+	logger := r.logger.With("hz", hzID)
+
+	rrs, err := r.rrsGetter.ListRecordSets(ctx, hzID)
+	if err != nil {
+		return err
+	}
+	// Index the txt record sets by the host that they own.
+	txts := map[string][]route53.ResourceRecordSet{}
+	for _, rs := range rrs {
+		if rs.Type != route53.RRTypeTxt {
+			continue
+		}
+		host, ok := r.cfg.Naming.Host(strings.TrimSuffix(aws.StringValue(rs.Name), "."))
+		if ok {
+			txts[host] = append(txts[host], rs)
+		}
+	}
+
+	changes := []route53.Change{}
+	for _, entry := range entries {
+		logger := logger.With("host", entry.Host)
+		host := strings.TrimSuffix(entry.Host, ".")
+		if len(txts[host]) == 0 {
+			logger.Warningf("txt record set not present, ignoring")
+			continue
+		}
+
+		// Each set identifier (and record type when type prefixed) has its own txt.
+		for _, rs := range txts[host] {
+			if !isAdoptionTXT(rs, entry) {
+				logger.Warningf("txt record set value changed since the adoption, leaving it")
+				continue
+			}
+			rs := rs
+			if len(rs.ResourceRecords) > 1 {
+				changes = append(changes, route53.Change{
+					Action:            route53.ChangeActionUpsert,
+					ResourceRecordSet: unmergeTXT(rs, entry),
+				})
+				logger.Infof("txt record set ownership value will be removed")
+				continue
+			}
+			changes = append(changes, route53.Change{
+				Action:            route53.ChangeActionDelete,
+				ResourceRecordSet: &rs,
+			})
+			logger.Infof("txt record set will be deleted")
+		}
+	}
+
+	return r.send(ctx, hzID, changes, logger)
+}
+
+// send sends the rollback changes of the hosted zone.
+func (r *rollbacker) send(ctx context.Context, hzID string, changes []route53.Change, logger log.Logger) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	if r.cfg.DryRun {
+		logger.Infof("not deleting %d txt record sets because of dry-run", len(changes))
+		return nil
+	}
+
+	_, err := adopt.SendChanges(ctx, r.r53Svc, hzID, "Remove txt entries", changes)
+	if err != nil {
+		return fmt.Errorf("error deleting txt record sets on %s: %s", hzID, err)
+	}
+	logger.Infof("%d txt record sets rolled back", len(changes))
+
+	return nil
+}
+
+func (r *rollbacker) RollbackResults(ctx context.Context, results []*model.Result) error {
+	// Group by hosted zone so the deletes are batched per zone.
+	zoneResults := map[string][]*model.Result{}
+	for _, res := range results {
+		id := res.ZoneID
+		if id == "" {
+			hz, err := r.zones.Find(ctx, res.Host)
+			if err != nil {
+				r.logger.With("host", res.Host).Warningf("can't rollback: %s", err)
+				continue
+			}
+			id = aws.StringValue(hz.Id)
+		}
+		zoneResults[id] = append(zoneResults[id], res)
+	}
+
+	for hzID, results := range zoneResults {
+		err := r.rollbackZoneResults(ctx, hzID, results)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *rollbacker) rollbackZoneResults(ctx context.Context, hzID string, results []*model.Result) error {
+	logger := r.logger.With("hz", hzID)
+
+	// Only the written txt record sets.
+	names := []string{}
+	for _, res := range results {
+		for _, txt := range res.TXTs {
+			names = append(names, txt.Name)
+		}
+	}
+	rrs, err := r.rrsGetter.GetRecordSets(ctx, hzID, names...)
+	if err != nil {
+		return err
+	}
+	txts := map[string]route53.ResourceRecordSet{}
+	for _, rs := range rrs {
+		if rs.Type == route53.RRTypeTxt {
+			txts[txtKey(aws.StringValue(rs.Name), aws.StringValue(rs.SetIdentifier))] = rs
+		}
+	}
+
+	changes := []route53.Change{}
+	seen := map[string]bool{}
+	for _, res := range results {
+		logger := logger.With("host", res.Host)
+		if len(res.TXTs) == 0 {
+			logger.Warningf("no txt record sets written on the result, ignoring")
+			continue
+		}
+
+		for _, txt := range res.TXTs {
+			key := txtKey(txt.Name, txt.SetIdentifier)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			rs, ok := txts[key]
+			if !ok {
+				logger.Warningf("txt record set %s not present, ignoring", txt.Name)
+				continue
+			}
+			ch, ok := rollbackChange(rs, txt)
+			if !ok {
+				logger.Warningf("txt record set %s value changed since the adoption, leaving it", txt.Name)
+				continue
+			}
+			changes = append(changes, ch)
+			logger.Infof("txt record set %s will be rolled back", txt.Name)
+		}
+	}
+
+	return r.send(ctx, hzID, changes, logger)
+}
+
+// rollbackChange returns the change that removes the written TXT from the record set, only
+// if the record set still has the written value (and only it when it wasn't merged).
+func rollbackChange(rs route53.ResourceRecordSet, txt model.TXT) (route53.Change, bool) {
+	values := []route53.ResourceRecord{}
+	found := false
+	for _, r := range rs.ResourceRecords {
+		if aws.StringValue(r.Value) == txt.Value {
+			found = true
+			continue
+		}
+		values = append(values, r)
+	}
+
+	switch {
+	case !found:
+		return route53.Change{}, false
+	case len(values) == 0:
+		return route53.Change{Action: route53.ChangeActionDelete, ResourceRecordSet: &rs}, true
+	case !txt.Merged:
+		return route53.Change{}, false
+	}
+
+	unmerged := rs
+	unmerged.ResourceRecords = values
+	return route53.Change{Action: route53.ChangeActionUpsert, ResourceRecordSet: &unmerged}, true
+}
+
+// txtKey returns the key of a TXT record set.
+func txtKey(name, setID string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + "|" + setID
+}
+
+// isAdoptionTXT returns true if the record set has exactly the value that the adoption writes,
+// or the value merged with values that are not from the registry.
+func isAdoptionTXT(rs route53.ResourceRecordSet, entry *model.Entry) bool {
+	txt := adoptionTXT(entry)
+	found := false
+	for _, r := range rs.ResourceRecords {
+		v := aws.StringValue(r.Value)
+		switch {
+		case v == txt:
+			found = true
+		case isRegistryValue(v):
+			return false
+		}
+	}
+	return found
+}
+
+// unmergeTXT returns the record set without the ownership value of the entry.
+func unmergeTXT(rs route53.ResourceRecordSet, entry *model.Entry) *route53.ResourceRecordSet {
+	txt := adoptionTXT(entry)
+	res := rs
+	res.ResourceRecords = []route53.ResourceRecord{}
+	for _, r := range rs.ResourceRecords {
+		if aws.StringValue(r.Value) != txt {
+			res.ResourceRecords = append(res.ResourceRecords, r)
+		}
+	}
+	return &res
+}
+
+func adoptionTXT(entry *model.Entry) string {
+	return fmt.Sprintf(`"%s"`, strings.Trim(entry.TXT, `"`))
+}
+
+func isRegistryValue(v string) bool {
+	_, err := registry.ParseTXT(v)
+	return err == nil
+}
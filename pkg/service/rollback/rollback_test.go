@@ -0,0 +1,193 @@
+package rollback_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/mocks"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/rollback"
+)
+
+func TestRollbackerRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		dryRun      bool
+		filter      string
+		expChanges  []string
+		expFound    int
+		expNoChange bool
+	}{
+		{
+			name:     "Rolling back should delete only the txt record sets created by the adoption and remove the merged values.",
+			filter:   `.*`,
+			expFound: 3,
+			expChanges: []string{
+				"DELETE batman.gotham.dc.comics.",
+				"DELETE robin.gotham.dc.comics.",
+				"UPSERT selina.gotham.dc.comics.",
+			},
+		},
+		{
+			name:       "Rolling back should delete only the filtered hosts.",
+			filter:     `^robin\..*`,
+			expFound:   1,
+			expChanges: []string{"DELETE robin.gotham.dc.comics."},
+		},
+		{
+			name:        "Rolling back in dry-run mode shouldn't delete anything.",
+			dryRun:      true,
+			filter:      `.*`,
+			expFound:    3,
+			expNoChange: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mocks.ListHostedZonesRequest(
+				route53.HostedZone{Name: aws.String("gotham.dc.comics."), Id: aws.String("gotham")},
+			))
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mocks.ListResourceRecordSetsRequest(
+				mocks.TXTRecordSet("batman.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=batman"`),
+				mocks.TXTRecordSet("robin.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=batman"`),
+				// Touched by external-dns.
+				mocks.TXTRecordSet("alfred.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=batman,external-dns/resource=ingress/gotham/alfred"`),
+				// Other owner.
+				mocks.TXTRecordSet("joker.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=joker"`),
+				// Merged into an existing TXT.
+				mocks.TXTRecordSet("selina.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=batman"`, `"v=spf1 -all"`),
+			))
+			if !test.expNoChange {
+				mbf := func(input *route53.ChangeResourceRecordSetsInput) bool {
+					if aws.StringValue(input.HostedZoneId) != "gotham" || len(input.ChangeBatch.Changes) != len(test.expChanges) {
+						return false
+					}
+					for i, ch := range input.ChangeBatch.Changes {
+						if fmt.Sprintf("%s %s", ch.Action, aws.StringValue(ch.ResourceRecordSet.Name)) != test.expChanges[i] {
+							return false
+						}
+						// Only the ownership value is removed.
+						if ch.Action == route53.ChangeActionUpsert &&
+							(len(ch.ResourceRecordSet.ResourceRecords) != 1 || aws.StringValue(ch.ResourceRecordSet.ResourceRecords[0].Value) != `"v=spf1 -all"`) {
+							return false
+						}
+					}
+					return true
+				}
+				mr53.On("ChangeResourceRecordSetsRequest", mock.MatchedBy(mbf)).Once().Return(mocks.ChangeResourceRecordSetsRequest())
+			}
+
+			fsvc, err := filter.NewEntryValidator(test.filter, "batman")
+			require.NoError(err)
+			rb := rollback.NewRollbacker(rollback.Config{DryRun: test.dryRun}, mr53, log.Dummy)
+
+			entries, err := rb.Find(context.Background(), fsvc)
+			require.NoError(err)
+			assert.Len(entries, test.expFound)
+
+			err = rb.Rollback(context.Background(), entries)
+			if assert.NoError(err) {
+				mr53.AssertExpectations(t)
+				if test.expNoChange {
+					mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+				}
+			}
+		})
+	}
+}
+
+func TestRollbackerRollbackResults(t *testing.T) {
+	const (
+		batmanTXT = `"heritage=external-dns,external-dns/owner=batman"`
+		robinTXT  = `"heritage=external-dns,external-dns/owner=robin"`
+	)
+
+	weighted := func(setID string) route53.ResourceRecordSet {
+		rs := mocks.TXTRecordSet("alfred.gotham.dc.comics.", batmanTXT)
+		rs.SetIdentifier = aws.String(setID)
+		return rs
+	}
+
+	tests := []struct {
+		name       string
+		results    []*model.Result
+		expChanges []string
+	}{
+		{
+			name: "Rolling back the results should delete only the written txt record sets with the written values.",
+			results: []*model.Result{
+				{Host: "batman.gotham.dc.comics", ZoneID: "gotham", TXTs: []model.TXT{{Name: "batman.gotham.dc.comics", Value: batmanTXT}}},
+				// Owner assigned by a rule.
+				{Host: "robin.gotham.dc.comics", ZoneID: "gotham", TXTs: []model.TXT{{Name: "robin.gotham.dc.comics", Value: robinTXT}}},
+				// Changed since the adoption.
+				{Host: "joker.gotham.dc.comics", ZoneID: "gotham", TXTs: []model.TXT{{Name: "joker.gotham.dc.comics", Value: batmanTXT}}},
+				{Host: "selina.gotham.dc.comics", ZoneID: "gotham", TXTs: []model.TXT{{Name: "selina.gotham.dc.comics", Value: batmanTXT, Merged: true}}},
+				{Host: "alfred.gotham.dc.comics", ZoneID: "gotham", TXTs: []model.TXT{{Name: "alfred.gotham.dc.comics", SetIdentifier: "a", Value: batmanTXT}}},
+			},
+			expChanges: []string{
+				"DELETE batman.gotham.dc.comics. ",
+				"DELETE robin.gotham.dc.comics. ",
+				"UPSERT selina.gotham.dc.comics. ",
+				"DELETE alfred.gotham.dc.comics. a",
+			},
+		},
+		{
+			name: "Rolling back the results without written txt record sets shouldn't delete anything.",
+			results: []*model.Result{
+				{Host: "batman.gotham.dc.comics", ZoneID: "gotham"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mocks.ListResourceRecordSetsRequest(
+				mocks.TXTRecordSet("batman.gotham.dc.comics.", batmanTXT),
+				// Written by external-dns with the same value, not by the adoption.
+				mocks.TXTRecordSet("cname-batman.gotham.dc.comics.", batmanTXT),
+				mocks.TXTRecordSet("robin.gotham.dc.comics.", robinTXT),
+				mocks.TXTRecordSet("joker.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=joker"`),
+				mocks.TXTRecordSet("selina.gotham.dc.comics.", batmanTXT, `"v=spf1 -all"`),
+				weighted("a"),
+				weighted("b"),
+			))
+			gotChanges := []string{}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Run(func(args mock.Arguments) {
+				input := args.Get(0).(*route53.ChangeResourceRecordSetsInput)
+				for _, ch := range input.ChangeBatch.Changes {
+					gotChanges = append(gotChanges, fmt.Sprintf("%s %s %s", ch.Action, aws.StringValue(ch.ResourceRecordSet.Name), aws.StringValue(ch.ResourceRecordSet.SetIdentifier)))
+				}
+			}).Return(mocks.ChangeResourceRecordSetsRequest())
+
+			rb := rollback.NewRollbacker(rollback.Config{}, mr53, log.Dummy)
+			err := rb.RollbackResults(context.Background(), test.results)
+			if assert.NoError(err) {
+				if len(test.expChanges) == 0 {
+					mr53.AssertNotCalled(t, "ChangeResourceRecordSetsRequest", mock.Anything)
+					return
+				}
+				assert.Equal(test.expChanges, gotChanges)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package simulate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+var actionSymbols = map[Action]string{
+	ActionCreate:   "+",
+	ActionUpdate:   "~",
+	ActionDelete:   "-",
+	ActionConflict: "!",
+	ActionNone:     "=",
+	ActionSkip:     "?",
+}
+
+// WriteDiff writes the plan in a diff format, unchanged hosts are only written
+// if verbose is set.
+func WriteDiff(w io.Writer, plan *Plan, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	zone := ""
+	for _, ch := range plan.Changes {
+		if ch.Action == ActionNone && !verbose {
+			continue
+		}
+
+		if ch.Zone != zone || zone == "" {
+			zone = ch.Zone
+			title := zone
+			if title == "" {
+				title = "<no hosted zone>"
+			}
+			fmt.Fprintf(tw, "--- %s (owner: %s)\n", title, plan.OwnerID)
+		}
+
+		state := ""
+		switch ch.Action {
+		case ActionCreate:
+			state = formatRecord(ch.Desired)
+		case ActionUpdate:
+			state = fmt.Sprintf("%s -> %s", formatRecord(ch.Current), formatRecord(ch.Desired))
+		case ActionDelete, ActionNone:
+			state = formatRecord(ch.Current)
+		}
+
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", actionSymbols[ch.Action], ch.Action, ch.Host, state, ch.Reason)
+	}
+
+	return tw.Flush()
+}
+
+func formatRecord(r *Record) string {
+	if r == nil {
+		return ""
+	}
+
+	ttl := fmt.Sprintf("default(%d)", defTTL)
+	if r.TTL != 0 {
+		ttl = fmt.Sprintf("%d", r.TTL)
+	}
+	rec := fmt.Sprintf("%s %s [%s]", r.Type, ttl, strings.Join(r.Targets, ","))
+	if r.SetIdentifier != "" {
+		rec = fmt.Sprintf("%s (set: %s)", rec, r.SetIdentifier)
+	}
+	return rec
+}
@@ -0,0 +1,401 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+)
+
+const (
+	// defTTL is the TTL that external-dns uses when the TTL is not configured.
+	defTTL = 300
+)
+
+// Policy is the external-dns policy used to synchronize the records.
+type Policy string
+
+// Policies.
+const (
+	// SyncPolicy creates, updates and deletes records.
+	SyncPolicy Policy = "sync"
+	// UpsertOnlyPolicy creates and updates records.
+	UpsertOnlyPolicy Policy = "upsert-only"
+)
+
+// Action is the action that external-dns will do on a host.
+type Action string
+
+// Actions.
+const (
+	ActionCreate   Action = "CREATE"
+	ActionUpdate   Action = "UPDATE"
+	ActionDelete   Action = "DELETE"
+	ActionConflict Action = "CONFLICT"
+	ActionNone     Action = "NONE"
+	ActionSkip     Action = "SKIP"
+)
+
+// Record is the state of a record set from the external-dns point of view.
+type Record struct {
+	Type          string
+	SetIdentifier string
+	TTL           int64
+	Targets       []string
+}
+
+// Change is a change that external-dns will do on its next sync.
+type Change struct {
+	Action  Action
+	Zone    string
+	Host    string
+	Current *Record
+	Desired *Record
+	Reason  string
+}
+
+// Plan is the external-dns plan after the adoption.
+type Plan struct {
+	OwnerID string
+	Changes []Change
+}
+
+// Simulator simulates the plan that external-dns will apply after adopting the hosts.
+type Simulator interface {
+	Simulate(ctx context.Context, endpoints []*model.Endpoint) (*Plan, error)
+}
+
+// Config is the configuration of the simulator.
+type Config struct {
+	// OwnerID is the external-dns owner ID used on the adoption.
+	OwnerID string
+	// Policy is the external-dns policy.
+	Policy Policy
+	// Naming is the naming of the external-dns TXT registry records.
+	Naming registry.Naming
+}
+
+type simulator struct {
+	cfg       Config
+	zones     adopt.ZoneIndex
+	rrsGetter adopt.RecordSetGetter
+	logger    log.Logger
+}
+
+// NewSimulator returns a new Simulator.
+func NewSimulator(cfg Config, zones adopt.ZoneIndex, rrsGetter adopt.RecordSetGetter, logger log.Logger) (Simulator, error) {
+	switch cfg.Policy {
+	case SyncPolicy, UpsertOnlyPolicy:
+	default:
+		return nil, fmt.Errorf("invalid policy %q", cfg.Policy)
+	}
+
+	return &simulator{
+		cfg:       cfg,
+		zones:     zones,
+		rrsGetter: rrsGetter,
+		logger:    logger,
+	}, nil
+}
+
+func (s *simulator) Simulate(ctx context.Context, endpoints []*model.Endpoint) (*Plan, error) {
+	plan := &Plan{OwnerID: s.cfg.OwnerID}
+
+	// Group the endpoints by hosted zone.
+	zones := map[string]route53.HostedZone{}
+	zoneEndpoints := map[string][]*model.Endpoint{}
+	for _, ep := range endpoints {
+		hz, err := s.zones.Find(ctx, ep.Host)
+		if err != nil {
+			plan.Changes = append(plan.Changes, Change{
+				Action: ActionSkip,
+				Host:   normalizeHost(ep.Host),
+				Reason: err.Error(),
+			})
+			continue
+		}
+		id := aws.StringValue(hz.Id)
+		zones[id] = *hz
+		zoneEndpoints[id] = append(zoneEndpoints[id], ep)
+	}
+
+	for id, eps := range zoneEndpoints {
+		changes, err := s.simulateZone(ctx, zones[id], eps)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		if plan.Changes[i].Zone != plan.Changes[j].Zone {
+			return plan.Changes[i].Zone < plan.Changes[j].Zone
+		}
+		return plan.Changes[i].Host < plan.Changes[j].Host
+	})
+
+	return plan, nil
+}
+
+// simulateZone simulates the external-dns plan of a single hosted zone based
+// on the current snapshot of the zone.
+func (s *simulator) simulateZone(ctx context.Context, hz route53.HostedZone, endpoints []*model.Endpoint) ([]Change, error) {
+	zoneName := strings.TrimSuffix(aws.StringValue(hz.Name), ".")
+	logger := s.logger.With("hz", aws.StringValue(hz.Id))
+
+	rrs, err := s.rrsGetter.ListRecordSets(ctx, aws.StringValue(hz.Id))
+	if err != nil {
+		return nil, err
+	}
+	snapshot := newZoneSnapshot(rrs, s.cfg.Naming, s.cfg.OwnerID)
+
+	changes := []Change{}
+	desiredSets := map[ownerKey]bool{}
+	for _, ep := range endpoints {
+		host := normalizeHost(ep.Host)
+		desired := desiredRecord(ep)
+		key := ownerKey{host: host, setID: desired.SetIdentifier}
+		desiredSets[key] = true
+		current := snapshot.current(host, desired)
+		ch := Change{
+			Zone:    zoneName,
+			Host:    host,
+			Current: current,
+			Desired: desired,
+		}
+
+		owner, owned := snapshot.owners[key]
+		switch {
+		// Owned by other external-dns, it will not be touched.
+		case owned && owner != s.cfg.OwnerID:
+			ch.Action = ActionConflict
+			ch.Reason = fmt.Sprintf("owned by %q", owner)
+		// Unrelated TXT, the adoption can't be done.
+		case snapshot.foreignTXT[key] && snapshot.hasRecords(key):
+			ch.Action = ActionConflict
+			ch.Reason = "non registry txt record set blocks the adoption"
+		case current == nil:
+			ch.Action = ActionCreate
+		case needsUpdate(current, desired):
+			ch.Action = ActionUpdate
+		default:
+			ch.Action = ActionNone
+		}
+
+		logger.Debugf("%s will be %s", host, ch.Action)
+		changes = append(changes, ch)
+	}
+
+	// Records already owned by us that are not desired will be deleted.
+	if s.cfg.Policy == SyncPolicy {
+		for _, key := range snapshot.keys() {
+			set := ownerKey{host: key.host, setID: key.setID}
+			if owner, ok := snapshot.owners[set]; !ok || owner != s.cfg.OwnerID || desiredSets[set] {
+				continue
+			}
+			changes = append(changes, Change{
+				Action:  ActionDelete,
+				Zone:    zoneName,
+				Host:    key.host,
+				Current: snapshot.records[key],
+				Reason:  "owned record not present on the desired hosts",
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// recordKey identifies a record set of a hosted zone.
+type recordKey struct {
+	host  string
+	typ   string
+	setID string
+}
+
+// ownerKey identifies the registry TXT records of a host, external-dns has one
+// ownership for each set identifier.
+type ownerKey struct {
+	host  string
+	setID string
+}
+
+// zoneSnapshot is the state of a hosted zone indexed by record set.
+type zoneSnapshot struct {
+	records    map[recordKey]*Record
+	owners     map[ownerKey]string
+	foreignTXT map[ownerKey]bool
+}
+
+func newZoneSnapshot(rrs []route53.ResourceRecordSet, naming registry.Naming, ownerID string) *zoneSnapshot {
+	z := &zoneSnapshot{
+		records:    map[recordKey]*Record{},
+		owners:     map[ownerKey]string{},
+		foreignTXT: map[ownerKey]bool{},
+	}
+
+	for _, rs := range rrs {
+		name := normalizeHost(aws.StringValue(rs.Name))
+		setID := aws.StringValue(rs.SetIdentifier)
+		switch rs.Type {
+		case route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname:
+			rec := currentRecord(rs)
+			z.records[recordKey{host: name, typ: rec.Type, setID: setID}] = rec
+		case route53.RRTypeTxt:
+			host, ok := naming.Host(name)
+			if !ok {
+				continue
+			}
+			key := ownerKey{host: host, setID: setID}
+			// The ownership can be merged with other values on the same TXT.
+			owner, ok := registry.TXTOwner(rs)
+			if !ok {
+				z.foreignTXT[key] = true
+				continue
+			}
+			// With type prefixed TXTs there can be more than one owner, any
+			// other owner wins over ours.
+			if current, ok := z.owners[key]; ok && current != ownerID {
+				continue
+			}
+			z.owners[key] = owner
+		}
+	}
+
+	return z
+}
+
+// current returns the record set that external-dns will compare with the
+// desired record. Route53 doesn't allow a CNAME with other types on the same
+// name so these are returned to be replaced by the desired record, other
+// types (e.g. an AAAA for a desired A) are left as they are.
+func (z *zoneSnapshot) current(host string, desired *Record) *Record {
+	if rec, ok := z.records[recordKey{host: host, typ: desired.Type, setID: desired.SetIdentifier}]; ok {
+		return rec
+	}
+
+	for _, key := range z.keys() {
+		if key.host != host || key.setID != desired.SetIdentifier {
+			continue
+		}
+		if key.typ == string(route53.RRTypeCname) || desired.Type == string(route53.RRTypeCname) {
+			return z.records[key]
+		}
+	}
+
+	return nil
+}
+
+// hasRecords returns if the host has record sets on the set identifier.
+func (z *zoneSnapshot) hasRecords(key ownerKey) bool {
+	for k := range z.records {
+		if k.host == key.host && k.setID == key.setID {
+			return true
+		}
+	}
+	return false
+}
+
+// keys returns the record set keys sorted.
+func (z *zoneSnapshot) keys() []recordKey {
+	keys := make([]recordKey, 0, len(z.records))
+	for k := range z.records {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		if keys[i].setID != keys[j].setID {
+			return keys[i].setID < keys[j].setID
+		}
+		return keys[i].typ < keys[j].typ
+	})
+	return keys
+}
+
+// currentRecord returns the record as external-dns sees it. Alias records
+// are seen as CNAMEs without TTL.
+func currentRecord(rs route53.ResourceRecordSet) *Record {
+	if rs.AliasTarget != nil {
+		return &Record{
+			Type:          string(route53.RRTypeCname),
+			SetIdentifier: aws.StringValue(rs.SetIdentifier),
+			Targets:       []string{normalizeHost(aws.StringValue(rs.AliasTarget.DNSName))},
+		}
+	}
+
+	targets := []string{}
+	for _, r := range rs.ResourceRecords {
+		targets = append(targets, normalizeHost(aws.StringValue(r.Value)))
+	}
+	sort.Strings(targets)
+
+	return &Record{
+		Type:          string(rs.Type),
+		SetIdentifier: aws.StringValue(rs.SetIdentifier),
+		TTL:           aws.Int64Value(rs.TTL),
+		Targets:       targets,
+	}
+}
+
+// desiredRecord returns the record that external-dns will want for the endpoint.
+func desiredRecord(ep *model.Endpoint) *Record {
+	targets := ep.Targets
+	if t, ok := ep.Annotations[model.TargetAnnotation]; ok {
+		targets = strings.Split(t, ",")
+	}
+
+	rec := &Record{
+		Type:          string(route53.RRTypeA),
+		SetIdentifier: ep.Annotations[model.SetIdentifierAnnotation],
+		TTL:           ep.TTL,
+	}
+	for _, t := range targets {
+		t = normalizeHost(strings.TrimSpace(t))
+		ip := net.ParseIP(t)
+		switch {
+		case ip == nil:
+			rec.Type = string(route53.RRTypeCname)
+		case ip.To4() == nil:
+			rec.Type = string(route53.RRTypeAaaa)
+		}
+		rec.Targets = append(rec.Targets, t)
+	}
+	sort.Strings(rec.Targets)
+
+	if ttl, ok := ep.Annotations[model.TTLAnnotation]; ok && rec.TTL == 0 {
+		if v, err := strconv.ParseInt(ttl, 10, 64); err == nil {
+			rec.TTL = v
+		}
+	}
+
+	return rec
+}
+
+// needsUpdate returns if external-dns will update the current record to
+// the desired one. The TTL is only taken into account when it's configured.
+func needsUpdate(current, desired *Record) bool {
+	if current.Type != desired.Type {
+		return true
+	}
+	if strings.Join(current.Targets, ",") != strings.Join(desired.Targets, ",") {
+		return true
+	}
+
+	return desired.TTL != 0 && desired.TTL != current.TTL
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -0,0 +1,204 @@
+package simulate_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/simulate"
+)
+
+func mockListHostedZones() route53.ListHostedZonesRequest {
+	return route53.ListHostedZonesRequest{
+		Request: &aws.Request{
+			HTTPRequest: &http.Request{},
+			Data: &route53.ListHostedZonesOutput{
+				HostedZones: []route53.HostedZone{
+					{Name: aws.String("gotham.dc.comics."), Id: aws.String("gotham")},
+				},
+			},
+		},
+	}
+}
+
+func rrs(name string, t route53.RRType, ttl int64, values ...string) route53.ResourceRecordSet {
+	rs := route53.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: t,
+		TTL:  aws.Int64(ttl),
+	}
+	for _, v := range values {
+		rs.ResourceRecords = append(rs.ResourceRecords, route53.ResourceRecord{Value: aws.String(v)})
+	}
+	return rs
+}
+
+func weightedRRs(name string, t route53.RRType, setID string, values ...string) route53.ResourceRecordSet {
+	rs := rrs(name, t, 300, values...)
+	rs.SetIdentifier = aws.String(setID)
+	rs.Weight = aws.Int64(50)
+	return rs
+}
+
+func mockListResourceRecordSets(rrss []route53.ResourceRecordSet) route53.ListResourceRecordSetsRequest {
+	return route53.ListResourceRecordSetsRequest{
+		Request: &aws.Request{
+			HTTPRequest: &http.Request{},
+			Data: &route53.ListResourceRecordSetsOutput{
+				ResourceRecordSets: rrss,
+			},
+		},
+	}
+}
+
+// changeKey returns the key of the change on the expectations, the host and
+// the set identifier if any.
+func changeKey(ch simulate.Change) string {
+	rec := ch.Desired
+	if rec == nil {
+		rec = ch.Current
+	}
+	if rec == nil || rec.SetIdentifier == "" {
+		return ch.Host
+	}
+	return ch.Host + "/" + rec.SetIdentifier
+}
+
+func TestSimulatorSimulate(t *testing.T) {
+	ownershipRRs := []route53.ResourceRecordSet{
+		rrs("unowned.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.1"),
+		rrs("changed.gotham.dc.comics.", route53.RRTypeCname, 300, "old.elb.amazonaws.com"),
+		rrs("ours.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.2"),
+		rrs("ours.gotham.dc.comics.", route53.RRTypeTxt, 300, `"heritage=external-dns,external-dns/owner=batman"`),
+		rrs("gone.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.3"),
+		rrs("gone.gotham.dc.comics.", route53.RRTypeTxt, 300, `"heritage=external-dns,external-dns/owner=batman"`),
+		rrs("joker.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.4"),
+		rrs("joker.gotham.dc.comics.", route53.RRTypeTxt, 300, `"heritage=external-dns,external-dns/owner=joker"`),
+		rrs("spf.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.5"),
+		rrs("spf.gotham.dc.comics.", route53.RRTypeTxt, 300, `"v=spf1 -all"`),
+		rrs("other.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.6"),
+		rrs("other.gotham.dc.comics.", route53.RRTypeTxt, 300, `"heritage=external-dns,external-dns/owner=joker"`),
+	}
+
+	tests := []struct {
+		name       string
+		policy     simulate.Policy
+		rrs        []route53.ResourceRecordSet
+		endpoints  []*model.Endpoint
+		expChanges map[string]simulate.Action
+	}{
+		{
+			name:   "Using sync policy should apply the external-dns ownership rules.",
+			policy: simulate.SyncPolicy,
+			rrs:    ownershipRRs,
+			endpoints: []*model.Endpoint{
+				{Host: "unowned.gotham.dc.comics", Targets: []string{"10.0.0.1"}},
+				{Host: "changed.gotham.dc.comics", Targets: []string{"new.elb.amazonaws.com"}},
+				{Host: "ours.gotham.dc.comics", Targets: []string{"10.0.0.2"}, Annotations: map[string]string{model.TTLAnnotation: "60"}},
+				{Host: "new.gotham.dc.comics", Targets: []string{"10.0.0.7"}},
+				{Host: "joker.gotham.dc.comics", Targets: []string{"10.0.0.4"}},
+				{Host: "spf.gotham.dc.comics", Targets: []string{"10.0.0.5"}},
+				{Host: "batman.metropolis.dc.comics", Targets: []string{"10.0.0.8"}},
+			},
+			expChanges: map[string]simulate.Action{
+				"unowned.gotham.dc.comics":    simulate.ActionNone,
+				"changed.gotham.dc.comics":    simulate.ActionUpdate,
+				"ours.gotham.dc.comics":       simulate.ActionUpdate,
+				"new.gotham.dc.comics":        simulate.ActionCreate,
+				"joker.gotham.dc.comics":      simulate.ActionConflict,
+				"spf.gotham.dc.comics":        simulate.ActionConflict,
+				"batman.metropolis.dc.comics": simulate.ActionSkip,
+				"gone.gotham.dc.comics":       simulate.ActionDelete,
+			},
+		},
+		{
+			name:   "Using upsert-only policy should not delete owned records.",
+			policy: simulate.UpsertOnlyPolicy,
+			rrs:    ownershipRRs,
+			endpoints: []*model.Endpoint{
+				{Host: "ours.gotham.dc.comics", Targets: []string{"10.0.0.2"}},
+			},
+			expChanges: map[string]simulate.Action{
+				"ours.gotham.dc.comics": simulate.ActionNone,
+			},
+		},
+		{
+			name:   "Hosts with multiple record set types should compare only the desired type.",
+			policy: simulate.SyncPolicy,
+			rrs: []route53.ResourceRecordSet{
+				rrs("dual.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.1"),
+				rrs("dual.gotham.dc.comics.", route53.RRTypeAaaa, 300, "2001:db8::1"),
+				rrs("dual.gotham.dc.comics.", route53.RRTypeTxt, 300, `"heritage=external-dns,external-dns/owner=batman"`),
+				rrs("ipv6.gotham.dc.comics.", route53.RRTypeAaaa, 300, "2001:db8::2"),
+				rrs("typed.gotham.dc.comics.", route53.RRTypeCname, 300, "10.0.0.3"),
+			},
+			endpoints: []*model.Endpoint{
+				{Host: "dual.gotham.dc.comics", Targets: []string{"10.0.0.1"}},
+				{Host: "ipv6.gotham.dc.comics", Targets: []string{"10.0.0.2"}},
+				{Host: "typed.gotham.dc.comics", Targets: []string{"10.0.0.3"}},
+			},
+			expChanges: map[string]simulate.Action{
+				"dual.gotham.dc.comics":  simulate.ActionNone,
+				"ipv6.gotham.dc.comics":  simulate.ActionCreate,
+				"typed.gotham.dc.comics": simulate.ActionUpdate,
+			},
+		},
+		{
+			name:   "Weighted hosts should apply the ownership rules for each set identifier.",
+			policy: simulate.SyncPolicy,
+			rrs: []route53.ResourceRecordSet{
+				weightedRRs("weighted.gotham.dc.comics.", route53.RRTypeA, "blue", "10.0.1.1"),
+				weightedRRs("weighted.gotham.dc.comics.", route53.RRTypeTxt, "blue", `"heritage=external-dns,external-dns/owner=batman"`),
+				weightedRRs("weighted.gotham.dc.comics.", route53.RRTypeA, "green", "10.0.1.2"),
+				weightedRRs("weighted.gotham.dc.comics.", route53.RRTypeTxt, "green", `"heritage=external-dns,external-dns/owner=joker"`),
+				weightedRRs("weighted.gotham.dc.comics.", route53.RRTypeA, "red", "10.0.1.3"),
+				weightedRRs("weighted.gotham.dc.comics.", route53.RRTypeTxt, "red", `"heritage=external-dns,external-dns/owner=batman"`),
+			},
+			endpoints: []*model.Endpoint{
+				{Host: "weighted.gotham.dc.comics", Targets: []string{"10.0.1.1"}, Annotations: map[string]string{model.SetIdentifierAnnotation: "blue"}},
+				{Host: "weighted.gotham.dc.comics", Targets: []string{"10.0.1.2"}, Annotations: map[string]string{model.SetIdentifierAnnotation: "green"}},
+				{Host: "weighted.gotham.dc.comics", Targets: []string{"10.0.1.4"}, Annotations: map[string]string{model.SetIdentifierAnnotation: "yellow"}},
+			},
+			expChanges: map[string]simulate.Action{
+				"weighted.gotham.dc.comics/blue":   simulate.ActionNone,
+				"weighted.gotham.dc.comics/green":  simulate.ActionConflict,
+				"weighted.gotham.dc.comics/yellow": simulate.ActionCreate,
+				"weighted.gotham.dc.comics/red":    simulate.ActionDelete,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mockListHostedZones())
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mockListResourceRecordSets(test.rrs))
+
+			sim, err := simulate.NewSimulator(simulate.Config{OwnerID: "batman", Policy: test.policy}, adopt.NewZoneIndex(mr53), adopt.NewRecordSetGetter(mr53), log.Dummy)
+			require.NoError(err)
+
+			plan, err := sim.Simulate(context.Background(), test.endpoints)
+			require.NoError(err)
+
+			gotChanges := map[string]simulate.Action{}
+			for _, ch := range plan.Changes {
+				gotChanges[changeKey(ch)] = ch.Action
+			}
+			assert.Equal(test.expChanges, gotChanges)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+)
+
+// Status is the overall status of an adoption.
+type Status string
+
+// Statuses.
+const (
+	// StatusSuccess is when none of the hosts failed.
+	StatusSuccess Status = "success"
+	// StatusPartialFailure is when some of the hosts failed.
+	StatusPartialFailure Status = "partial-failure"
+	// StatusFailure is when all the hosts failed.
+	StatusFailure Status = "failure"
+)
+
+// ZoneSummary are the outcome counts of the hosts of a hosted zone.
+type ZoneSummary struct {
+	// Zone is the hosted zone name, empty for the hosts without zone.
+	Zone   string                `json:"zone"`
+	Counts map[model.Outcome]int `json:"counts"`
+}
+
+// Summary are the outcome counts of an adoption.
+type Summary struct {
+	Zones  []*ZoneSummary        `json:"zones"`
+	Counts map[model.Outcome]int `json:"counts"`
+}
+
+// Status returns the overall status of the adoption.
+func (s *Summary) Status() Status {
+	succeeded, failed := 0, 0
+	for o, n := range s.Counts {
+		switch {
+		case o.Success():
+			succeeded += n
+		case o.Failure():
+			failed += n
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return StatusSuccess
+	case succeeded == 0:
+		return StatusFailure
+	}
+	return StatusPartialFailure
+}
+
+// Collector collects the results of the hosts of an adoption.
+type Collector interface {
+	// Handle counts the result of a host.
+	Handle(res *model.Result)
+	// Summary returns the summary of the collected results.
+	Summary() *Summary
+}
+
+type collector struct {
+	mu    sync.Mutex
+	zones map[string]map[model.Outcome]int
+}
+
+// NewCollector returns a new Collector.
+func NewCollector() Collector {
+	return &collector{
+		zones: map[string]map[model.Outcome]int{},
+	}
+}
+
+func (c *collector) Handle(res *model.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.zones[res.Zone] == nil {
+		c.zones[res.Zone] = map[model.Outcome]int{}
+	}
+	c.zones[res.Zone][res.Outcome]++
+}
+
+func (c *collector) Summary() *Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &Summary{
+		Zones:  []*ZoneSummary{},
+		Counts: map[model.Outcome]int{},
+	}
+	for zone, counts := range c.zones {
+		zs := &ZoneSummary{Zone: zone, Counts: map[model.Outcome]int{}}
+		for o, n := range counts {
+			zs.Counts[o] = n
+			s.Counts[o] += n
+		}
+		s.Zones = append(s.Zones, zs)
+	}
+	sort.Slice(s.Zones, func(i, j int) bool {
+		return s.Zones[i].Zone < s.Zones[j].Zone
+	})
+
+	return s
+}
+
+// WriteSummary writes the summary as a table with the counts per zone.
+func WriteSummary(w io.Writer, s *Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "ZONE")
+	for _, o := range model.Outcomes {
+		fmt.Fprintf(tw, "\t%s", strings.ToUpper(string(o)))
+	}
+	fmt.Fprintf(tw, "\n")
+
+	row := func(name string, counts map[model.Outcome]int) {
+		fmt.Fprintf(tw, "%s", name)
+		for _, o := range model.Outcomes {
+			fmt.Fprintf(tw, "\t%d", counts[o])
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+	for _, z := range s.Zones {
+		name := z.Zone
+		if name == "" {
+			name = "-"
+		}
+		row(name, z.Counts)
+	}
+	row("TOTAL", s.Counts)
+	fmt.Fprintf(tw, "\nSTATUS: %s\n", s.Status())
+
+	return tw.Flush()
+}
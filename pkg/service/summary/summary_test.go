@@ -0,0 +1,116 @@
+package summary_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/summary"
+)
+
+func TestCollectorSummary(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []*model.Result
+		expCounts map[model.Outcome]int
+		expZones  []string
+		expStatus summary.Status
+	}{
+		{
+			name:      "Without results it should be a success.",
+			results:   []*model.Result{},
+			expCounts: map[model.Outcome]int{},
+			expZones:  []string{},
+			expStatus: summary.StatusSuccess,
+		},
+		{
+			name: "Adopted and skipped hosts should be a success.",
+			results: []*model.Result{
+				{Host: "batman.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeAdopted},
+				{Host: "superman.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeAlreadyOwned},
+				{Host: "deadpool.marvel.comic.io", Outcome: model.OutcomeSkipped},
+			},
+			expCounts: map[model.Outcome]int{
+				model.OutcomeAdopted:      1,
+				model.OutcomeAlreadyOwned: 1,
+				model.OutcomeSkipped:      1,
+			},
+			expZones:  []string{"", "dc.comic.io"},
+			expStatus: summary.StatusSuccess,
+		},
+		{
+			name: "Adopted and failed hosts should be a partial failure.",
+			results: []*model.Result{
+				{Host: "batman.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeAdopted},
+				{Host: "spiderman.marvel.comic.io", Zone: "marvel.comic.io", Outcome: model.OutcomeOwnerConflict},
+				{Host: "wolverine.marvel.comic.io", Zone: "marvel.comic.io", Outcome: model.OutcomeMissingRecord},
+			},
+			expCounts: map[model.Outcome]int{
+				model.OutcomeAdopted:       1,
+				model.OutcomeOwnerConflict: 1,
+				model.OutcomeMissingRecord: 1,
+			},
+			expZones:  []string{"dc.comic.io", "marvel.comic.io"},
+			expStatus: summary.StatusPartialFailure,
+		},
+		{
+			name: "Only failed hosts should be a failure.",
+			results: []*model.Result{
+				{Host: "batman.dc.comic.io", Outcome: model.OutcomeNoZone},
+				{Host: "spiderman.marvel.comic.io", Zone: "marvel.comic.io", Outcome: model.OutcomeError},
+				{Host: "deadpool.marvel.comic.io", Outcome: model.OutcomeSkipped},
+			},
+			expCounts: map[model.Outcome]int{
+				model.OutcomeNoZone:  1,
+				model.OutcomeError:   1,
+				model.OutcomeSkipped: 1,
+			},
+			expZones:  []string{"", "marvel.comic.io"},
+			expStatus: summary.StatusFailure,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			c := summary.NewCollector()
+			for _, res := range test.results {
+				c.Handle(res)
+			}
+			s := c.Summary()
+
+			zones := []string{}
+			for _, z := range s.Zones {
+				zones = append(zones, z.Zone)
+			}
+			assert.Equal(test.expCounts, s.Counts)
+			assert.Equal(test.expZones, zones)
+			assert.Equal(test.expStatus, s.Status())
+		})
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	assert := assert.New(t)
+
+	c := summary.NewCollector()
+	c.Handle(&model.Result{Host: "batman.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeAdopted})
+	c.Handle(&model.Result{Host: "joker.dc.comic.io", Zone: "dc.comic.io", Outcome: model.OutcomeOwnerConflict})
+	c.Handle(&model.Result{Host: "deadpool.marvel.io", Outcome: model.OutcomeNoZone})
+
+	var b bytes.Buffer
+	err := summary.WriteSummary(&b, c.Summary())
+	if assert.NoError(err) {
+		exp := `ZONE         ADOPTED  ALREADY-OWNED  UNVERIFIED  OWNER-CONFLICT  EXISTING-TXT  MISSING-RECORD  UNSUPPORTED  NO-ZONE  ERROR  SKIPPED
+-            0        0              0           0               0             0               0            1        0      0
+dc.comic.io  1        0              0           1               0             0               0            0        0      0
+TOTAL        1        0              0           1               0             0               0            1        0      0
+
+STATUS: partial-failure
+`
+		assert.Equal(exp, b.String())
+	}
+}
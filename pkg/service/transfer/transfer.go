@@ -0,0 +1,166 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+)
+
+// Config is the configuration of the transferer.
+type Config struct {
+	// DryRun will not make any change on Route53.
+	DryRun bool
+	// Naming is the naming of the external-dns TXT registry records.
+	Naming registry.Naming
+}
+
+// Transferer knows how to transfer the ownership of records between external-dns owner IDs.
+type Transferer interface {
+	// Transfer rewrites the ownership TXT records of the hosts valid for the validator that
+	// are owned by the from owner ID to the to owner ID.
+	Transfer(ctx context.Context, validator filter.EntryValidator, fromOwnerID, toOwnerID string) error
+}
+
+type transferer struct {
+	cfg       Config
+	r53Svc    route53iface.Route53API
+	zones     adopt.ZoneIndex
+	rrsGetter adopt.RecordSetGetter
+	logger    log.Logger
+}
+
+// NewTransferer returns a new Transferer.
+func NewTransferer(cfg Config, r53Svc route53iface.Route53API, logger log.Logger) Transferer {
+	return &transferer{
+		cfg:       cfg,
+		r53Svc:    r53Svc,
+		zones:     adopt.NewZoneIndex(r53Svc),
+		rrsGetter: adopt.NewRecordSetGetter(r53Svc),
+		logger:    logger,
+	}
+}
+
+func (t *transferer) Transfer(ctx context.Context, validator filter.EntryValidator, fromOwnerID, toOwnerID string) error {
+	if fromOwnerID == toOwnerID {
+		return fmt.Errorf("can't transfer the ownership to the same owner id %s", toOwnerID)
+	}
+
+	zones, err := t.zones.Zones(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, hz := range zones {
+		err := t.transferZone(ctx, aws.StringValue(hz.Id), validator, fromOwnerID, toOwnerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *transferer) transferZone(ctx context.Context, hzID string, validator filter.EntryValidator, fromOwnerID, toOwnerID string) error {
+	logger := t.logger.With("hz", hzID)
+
+	rrs, err := t.rrsGetter.ListRecordSets(ctx, hzID)
+	if err != nil {
+		return err
+	}
+
+	changes := []route53.Change{}
+	for _, rs := range rrs {
+		if rs.Type != route53.RRTypeTxt {
+			continue
+		}
+		// The legacy and the type prefixed TXT records of the same host are transferred
+		// together so the host is never half transferred.
+		host, ok := validHost(ctx, validator, t.cfg.Naming.Hosts(strings.TrimSuffix(aws.StringValue(rs.Name), ".")))
+		if !ok {
+			continue
+		}
+
+		logger := logger.With("host", host)
+		newrs, owner, ok := transferRecordSet(rs, fromOwnerID, toOwnerID)
+		switch {
+		case owner == "":
+			logger.Debugf("not an ownership txt record set, ignoring")
+			continue
+		case owner == toOwnerID:
+			logger.Infof("already owned by %s", toOwnerID)
+			continue
+		case !ok:
+			logger.Warningf("refusing to transfer, owned by %s", owner)
+			continue
+		}
+
+		changes = append(changes, route53.Change{
+			Action:            route53.ChangeActionUpsert,
+			ResourceRecordSet: newrs,
+		})
+		logger.Infof("ownership will be transferred from %s to %s", fromOwnerID, toOwnerID)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	if t.cfg.DryRun {
+		logger.Infof("not transferring %d txt record sets because of dry-run", len(changes))
+		return nil
+	}
+
+	_, err = adopt.SendChanges(ctx, t.r53Svc, hzID, "Transfer txt entries ownership", changes)
+	if err != nil {
+		return fmt.Errorf("error transferring txt record sets on %s: %s", hzID, err)
+	}
+	logger.Infof("%d txt record sets transferred", len(changes))
+
+	return nil
+}
+
+// validHost returns the first of the hosts that is valid for the validator.
+func validHost(ctx context.Context, validator filter.EntryValidator, hosts []string) (string, bool) {
+	for _, host := range hosts {
+		if _, err := validator.Validate(ctx, host); err == nil {
+			return host, true
+		}
+	}
+	return "", false
+}
+
+// transferRecordSet returns the record set with the ownership transferred to the new owner,
+// the rest of values and labels are preserved. It also returns the current owner of the record
+// set and false if it can't be transferred.
+func transferRecordSet(rs route53.ResourceRecordSet, fromOwnerID, toOwnerID string) (*route53.ResourceRecordSet, string, bool) {
+	newrs := rs
+	newrs.ResourceRecords = []route53.ResourceRecord{}
+
+	owner := ""
+	for _, r := range rs.ResourceRecords {
+		labels, err := registry.ParseTXT(aws.StringValue(r.Value))
+		if err != nil {
+			newrs.ResourceRecords = append(newrs.ResourceRecords, r)
+			continue
+		}
+
+		owner = labels.Owner()
+		if owner != fromOwnerID {
+			return nil, owner, false
+		}
+		labels[registry.OwnerKey] = toOwnerID
+		newrs.ResourceRecords = append(newrs.ResourceRecords, route53.ResourceRecord{
+			Value: aws.String(fmt.Sprintf(`"%s"`, labels)),
+		})
+	}
+
+	return &newrs, owner, owner != ""
+}
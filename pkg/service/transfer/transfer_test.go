@@ -0,0 +1,102 @@
+package transfer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/mocks"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/filter"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/transfer"
+)
+
+func TestTransfererTransfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		rrs        []route53.ResourceRecordSet
+		expUpserts map[string][]string
+	}{
+		{
+			name: "Transferring with legacy names should upsert only the records of the old owner.",
+			rrs: []route53.ResourceRecordSet{
+				mocks.TXTRecordSet("batman.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=cluster-blue"`),
+				mocks.TXTRecordSet("robin.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=cluster-blue,external-dns/resource=ingress/gotham/robin"`, `"v=spf1 -all"`),
+				mocks.TXTRecordSet("joker.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=arkham"`),
+				mocks.TXTRecordSet("alfred.gotham.dc.comics.", `"google-site-verification=1234"`),
+				mocks.TXTRecordSet("nightwing.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=cluster-green"`),
+			},
+			expUpserts: map[string][]string{
+				"batman.gotham.dc.comics.": {`"heritage=external-dns,external-dns/owner=cluster-green"`},
+				"robin.gotham.dc.comics.":  {`"heritage=external-dns,external-dns/owner=cluster-green,external-dns/resource=ingress/gotham/robin"`, `"v=spf1 -all"`},
+			},
+		},
+		{
+			name:   "Transferring with prefixed names should also transfer the legacy records of the same hosts in the same batch.",
+			prefix: "%{record_type}-",
+			rrs: []route53.ResourceRecordSet{
+				mocks.TXTRecordSet("batman.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=cluster-blue"`),
+				mocks.TXTRecordSet("cname-batman.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=cluster-blue"`),
+			},
+			expUpserts: map[string][]string{
+				"batman.gotham.dc.comics.":       {`"heritage=external-dns,external-dns/owner=cluster-green"`},
+				"cname-batman.gotham.dc.comics.": {`"heritage=external-dns,external-dns/owner=cluster-green"`},
+			},
+		},
+		{
+			name: "Transferring with legacy names should also transfer the type prefixed records of the same hosts in the same batch.",
+			rrs: []route53.ResourceRecordSet{
+				mocks.TXTRecordSet("batman.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=cluster-blue"`),
+				mocks.TXTRecordSet("cname-batman.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=cluster-blue"`),
+				mocks.TXTRecordSet("a-robin.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=arkham"`),
+			},
+			expUpserts: map[string][]string{
+				"batman.gotham.dc.comics.":       {`"heritage=external-dns,external-dns/owner=cluster-green"`},
+				"cname-batman.gotham.dc.comics.": {`"heritage=external-dns,external-dns/owner=cluster-green"`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mocks.ListHostedZonesRequest(
+				route53.HostedZone{Name: aws.String("gotham.dc.comics."), Id: aws.String("gotham")},
+			))
+			mr53.On("ListResourceRecordSetsRequest", mock.Anything).Return(mocks.ListResourceRecordSetsRequest(test.rrs...))
+			gotUpserts := map[string][]string{}
+			mr53.On("ChangeResourceRecordSetsRequest", mock.Anything).Once().Run(func(args mock.Arguments) {
+				input := args.Get(0).(*route53.ChangeResourceRecordSetsInput)
+				for _, ch := range input.ChangeBatch.Changes {
+					assert.Equal(route53.ChangeActionUpsert, ch.Action)
+					for _, r := range ch.ResourceRecordSet.ResourceRecords {
+						name := aws.StringValue(ch.ResourceRecordSet.Name)
+						gotUpserts[name] = append(gotUpserts[name], aws.StringValue(r.Value))
+					}
+				}
+			}).Return(mocks.ChangeResourceRecordSetsRequest())
+
+			fsvc, err := filter.NewEntryValidator(`.*`, "cluster-green")
+			require.NoError(err)
+			tr := transfer.NewTransferer(transfer.Config{Naming: registry.Naming{Prefix: test.prefix}}, mr53, log.Dummy)
+
+			err = tr.Transfer(context.Background(), fsvc, "cluster-blue", "cluster-green")
+			if assert.NoError(err) {
+				mr53.AssertExpectations(t)
+				assert.Equal(test.expUpserts, gotUpserts)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+)
+
+const (
+	defTimeout  = 5 * time.Minute
+	defInterval = 5 * time.Second
+	defDNSPort  = "53"
+	// lookupTimeout is the maximum time of a single DNS query.
+	lookupTimeout = 5 * time.Second
+)
+
+// Config is the configuration of the verifier.
+type Config struct {
+	// Timeout is the maximum time waiting for a change to be INSYNC and served.
+	Timeout time.Duration
+	// Interval is the time between the checks.
+	Interval time.Duration
+	// DNSPort is the port of the nameservers.
+	DNSPort string
+}
+
+func (c *Config) defaults() {
+	if c.Timeout == 0 {
+		c.Timeout = defTimeout
+	}
+	if c.Interval == 0 {
+		c.Interval = defInterval
+	}
+	if c.DNSPort == "" {
+		c.DNSPort = defDNSPort
+	}
+}
+
+// TXT is a TXT record value that needs to be served.
+type TXT struct {
+	Name  string
+	Value string
+}
+
+// Verifier knows how to verify that the changes are applied.
+type Verifier interface {
+	// Verify waits until the Route53 change is INSYNC and the TXT values are served
+	// by all the authoritative nameservers of the hosted zone. Without a change ID
+	// (the TXT records already existed) only the nameservers are checked.
+	Verify(ctx context.Context, hzID, changeID string, txts []TXT) error
+}
+
+type verifier struct {
+	cfg    Config
+	r53Svc route53iface.Route53API
+	logger log.Logger
+
+	mu          sync.Mutex
+	nameservers map[string][]string
+}
+
+// NewVerifier returns a new Verifier.
+func NewVerifier(cfg Config, r53Svc route53iface.Route53API, logger log.Logger) Verifier {
+	cfg.defaults()
+	return &verifier{
+		cfg:         cfg,
+		r53Svc:      r53Svc,
+		logger:      logger,
+		nameservers: map[string][]string{},
+	}
+}
+
+func (v *verifier) Verify(ctx context.Context, hzID, changeID string, txts []TXT) error {
+	ctx, cancel := context.WithTimeout(ctx, v.cfg.Timeout)
+	defer cancel()
+	logger := v.logger.With("hz", hzID).With("change", changeID)
+
+	if changeID != "" {
+		if err := v.waitInSync(ctx, changeID); err != nil {
+			return err
+		}
+		logger.Debugf("change is INSYNC")
+	}
+
+	nss, err := v.zoneNameservers(ctx, hzID)
+	if err != nil {
+		return err
+	}
+	for _, ns := range nss {
+		for _, txt := range txts {
+			if err := v.waitServed(ctx, ns, txt); err != nil {
+				return err
+			}
+			logger.Debugf("%s txt served by %s", txt.Name, ns)
+		}
+	}
+
+	return nil
+}
+
+// waitInSync polls the change until it's INSYNC.
+func (v *verifier) waitInSync(ctx context.Context, changeID string) error {
+	for {
+		req := v.r53Svc.GetChangeRequest(&route53.GetChangeInput{Id: aws.String(changeID)})
+		req.SetContext(ctx)
+		resp, err := req.Send()
+		if err != nil {
+			return err
+		}
+		if resp.ChangeInfo != nil && resp.ChangeInfo.Status == route53.ChangeStatusInsync {
+			return nil
+		}
+
+		if err := v.wait(ctx); err != nil {
+			return fmt.Errorf("timeout waiting for change %s to be INSYNC: %s", changeID, err)
+		}
+	}
+}
+
+// zoneNameservers returns the nameservers of the delegation set of the hosted zone.
+func (v *verifier) zoneNameservers(ctx context.Context, hzID string) ([]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if nss, ok := v.nameservers[hzID]; ok {
+		return nss, nil
+	}
+
+	req := v.r53Svc.GetHostedZoneRequest(&route53.GetHostedZoneInput{Id: aws.String(hzID)})
+	req.SetContext(ctx)
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	if resp.DelegationSet == nil || len(resp.DelegationSet.NameServers) == 0 {
+		return nil, fmt.Errorf("hosted zone %s doesn't have a delegation set, can't verify over DNS", hzID)
+	}
+
+	v.nameservers[hzID] = resp.DelegationSet.NameServers
+	return resp.DelegationSet.NameServers, nil
+}
+
+// waitServed queries the nameserver until it serves the TXT value.
+func (v *verifier) waitServed(ctx context.Context, ns string, txt TXT) error {
+	var lastErr error
+	for {
+		values, err := v.lookupTXT(ctx, ns, txt.Name)
+		if err == nil {
+			for _, value := range values {
+				if value == strings.Trim(txt.Value, `"`) {
+					return nil
+				}
+			}
+			err = fmt.Errorf("value not present in %q", values)
+		}
+		lastErr = err
+
+		if err := v.wait(ctx); err != nil {
+			return fmt.Errorf("timeout waiting for %s txt to be served by %s: %s", txt.Name, ns, lastErr)
+		}
+	}
+}
+
+// wait waits the interval between checks, it fails if the context ends before.
+func (v *verifier) wait(ctx context.Context) error {
+	if dl, ok := ctx.Deadline(); ok && time.Now().Add(v.cfg.Interval).After(dl) {
+		return context.DeadlineExceeded
+	}
+
+	t := time.NewTimer(v.cfg.Interval)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// lookupTXT queries the TXT values of the name directly to the nameserver.
+func (v *verifier) lookupTXT(ctx context.Context, ns, name string) ([]string, error) {
+	r := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, network, net.JoinHostPort(ns, v.cfg.DNSPort))
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	// Fully qualified so the search domains are not used.
+	return r.LookupTXT(ctx, strings.TrimSuffix(name, ".")+".")
+}
@@ -0,0 +1,226 @@
+package verify_test
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/verify"
+)
+
+// dnsServer is a minimal authoritative UDP DNS server that only answers TXT queries.
+type dnsServer struct {
+	conn net.PacketConn
+
+	mu   sync.Mutex
+	txts map[string][]string
+}
+
+func newDNSServer(t *testing.T, txts map[string][]string) *dnsServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &dnsServer{conn: conn, txts: txts}
+	go s.serve()
+	return s
+}
+
+func (s *dnsServer) port() string {
+	return strings.Split(s.conn.LocalAddr().String(), ":")[1]
+}
+
+func (s *dnsServer) close() {
+	s.conn.Close()
+}
+
+func (s *dnsServer) serve() {
+	b := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(b)
+		if err != nil {
+			return
+		}
+		if resp := s.answer(b[:n]); resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *dnsServer) answer(q []byte) []byte {
+	if len(q) < 12 {
+		return nil
+	}
+
+	// Question name.
+	labels := []string{}
+	i := 12
+	for i < len(q) && q[i] != 0 {
+		l := int(q[i])
+		if i+1+l > len(q) {
+			return nil
+		}
+		labels = append(labels, string(q[i+1:i+1+l]))
+		i += 1 + l
+	}
+	end := i + 5 // Zero label, type and class.
+	if end > len(q) {
+		return nil
+	}
+	name := strings.ToLower(strings.Join(labels, "."))
+	qtype := binary.BigEndian.Uint16(q[i+1 : i+3])
+
+	s.mu.Lock()
+	values, ok := s.txts[name]
+	s.mu.Unlock()
+
+	// Header.
+	flags := uint16(0x8400) | binary.BigEndian.Uint16(q[2:4])&0x0100 // Response, authoritative and recursion desired.
+	if !ok {
+		flags |= 3 // NXDOMAIN.
+	}
+	if qtype != 16 {
+		values = nil
+	}
+	resp := make([]byte, 12)
+	copy(resp[0:2], q[0:2])
+	binary.BigEndian.PutUint16(resp[2:4], flags)
+	binary.BigEndian.PutUint16(resp[4:6], 1)
+	binary.BigEndian.PutUint16(resp[6:8], uint16(len(values)))
+	resp = append(resp, q[12:end]...)
+
+	// Answers.
+	for _, v := range values {
+		rr := []byte{0xc0, 0x0c, 0x00, 0x10, 0x00, 0x01, 0x00, 0x00, 0x00, 0x3c}
+		rr = append(rr, byte((len(v)+1)>>8), byte(len(v)+1), byte(len(v)))
+		rr = append(rr, v...)
+		resp = append(resp, rr...)
+	}
+
+	return resp
+}
+
+func mockGetChange(status route53.ChangeStatus) route53.GetChangeRequest {
+	return route53.GetChangeRequest{
+		Request: &aws.Request{
+			Data: &route53.GetChangeOutput{
+				ChangeInfo: &route53.ChangeInfo{Id: aws.String("/change/1"), Status: status},
+			},
+			HTTPRequest: &http.Request{},
+		},
+	}
+}
+
+func TestVerifierVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []route53.ChangeStatus
+		served   map[string][]string
+		txts     []verify.TXT
+		expErr   bool
+	}{
+		{
+			name:     "A change INSYNC with the TXT served by the nameservers should be verified.",
+			statuses: []route53.ChangeStatus{route53.ChangeStatusPending, route53.ChangeStatusInsync},
+			served: map[string][]string{
+				"batman.gotham.dc.comics": {"v=spf1 -all", "heritage=external-dns,external-dns/owner=batman"},
+			},
+			txts: []verify.TXT{{Name: "batman.gotham.dc.comics", Value: `"heritage=external-dns,external-dns/owner=batman"`}},
+		},
+		{
+			name:     "A change INSYNC without the TXT served by the nameservers should fail.",
+			statuses: []route53.ChangeStatus{route53.ChangeStatusInsync},
+			served: map[string][]string{
+				"batman.gotham.dc.comics": {"heritage=external-dns,external-dns/owner=joker"},
+			},
+			txts:   []verify.TXT{{Name: "batman.gotham.dc.comics", Value: `"heritage=external-dns,external-dns/owner=batman"`}},
+			expErr: true,
+		},
+		{
+			name:     "A change INSYNC with the TXT not existing on the nameservers should fail.",
+			statuses: []route53.ChangeStatus{route53.ChangeStatusInsync},
+			served:   map[string][]string{},
+			txts:     []verify.TXT{{Name: "batman.gotham.dc.comics", Value: `"heritage=external-dns,external-dns/owner=batman"`}},
+			expErr:   true,
+		},
+		{
+			name: "Without a change the TXT served by the nameservers should be verified.",
+			served: map[string][]string{
+				"batman.gotham.dc.comics": {"heritage=external-dns,external-dns/owner=batman"},
+			},
+			txts: []verify.TXT{{Name: "batman.gotham.dc.comics", Value: `"heritage=external-dns,external-dns/owner=batman"`}},
+		},
+		{
+			name: "Without a change the TXT not served by the nameservers should fail.",
+			served: map[string][]string{
+				"batman.gotham.dc.comics": {"heritage=external-dns,external-dns/owner=joker"},
+			},
+			txts:   []verify.TXT{{Name: "batman.gotham.dc.comics", Value: `"heritage=external-dns,external-dns/owner=batman"`}},
+			expErr: true,
+		},
+		{
+			name:     "A change that is never INSYNC should fail.",
+			statuses: []route53.ChangeStatus{route53.ChangeStatusPending},
+			served: map[string][]string{
+				"batman.gotham.dc.comics": {"heritage=external-dns,external-dns/owner=batman"},
+			},
+			txts:   []verify.TXT{{Name: "batman.gotham.dc.comics", Value: `"heritage=external-dns,external-dns/owner=batman"`}},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			srv := newDNSServer(t, test.served)
+			defer srv.close()
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			for i, st := range test.statuses {
+				call := mr53.On("GetChangeRequest", mock.Anything).Return(mockGetChange(st))
+				if i < len(test.statuses)-1 {
+					call.Once()
+				}
+			}
+			mr53.On("GetHostedZoneRequest", mock.Anything).Return(route53.GetHostedZoneRequest{
+				Request: &aws.Request{
+					Data: &route53.GetHostedZoneOutput{
+						DelegationSet: &route53.DelegationSet{NameServers: []string{"127.0.0.1"}},
+					},
+					HTTPRequest: &http.Request{},
+				},
+			})
+
+			v := verify.NewVerifier(verify.Config{
+				Timeout:  200 * time.Millisecond,
+				Interval: 10 * time.Millisecond,
+				DNSPort:  srv.port(),
+			}, mr53, log.Dummy)
+
+			changeID := ""
+			if len(test.statuses) > 0 {
+				changeID = "/change/1"
+			}
+			err := v.Verify(context.Background(), "gotham", changeID, test.txts)
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+)
+
+// Kind is the kind of change on a record set.
+type Kind string
+
+// Kinds.
+const (
+	KindCreated Kind = "created"
+	KindChanged Kind = "changed"
+	KindDeleted Kind = "deleted"
+)
+
+// Change is a change detected on a watched record set.
+type Change struct {
+	Time      time.Time
+	Zone      string
+	Host      string
+	RecordSet string
+	Kind      Kind
+	Details   []string
+}
+
+// Report are the changes detected while watching.
+type Report struct {
+	Changes []Change
+}
+
+// Deletions returns the number of deleted record sets.
+func (r *Report) Deletions() int {
+	n := 0
+	for _, ch := range r.Changes {
+		if ch.Kind == KindDeleted {
+			n++
+		}
+	}
+	return n
+}
+
+// Config is the configuration of the watcher.
+type Config struct {
+	// Duration is the time watching the record sets.
+	Duration time.Duration
+	// Interval is the time between the polls.
+	Interval time.Duration
+	// Naming is the naming of the external-dns TXT registry records.
+	Naming registry.Naming
+}
+
+// Watcher knows how to watch the adopted record sets to detect the changes that external-dns does.
+type Watcher interface {
+	// Watch snapshots the record sets of the adopted hosts and polls them during the watch
+	// duration, reporting every change. The watch ends early when a record set is deleted
+	// or the context is done.
+	Watch(ctx context.Context, results []*model.Result) (*Report, error)
+}
+
+type watcher struct {
+	cfg       Config
+	zones     adopt.ZoneIndex
+	rrsGetter adopt.RecordSetGetter
+	logger    log.Logger
+}
+
+// NewWatcher returns a new Watcher.
+func NewWatcher(cfg Config, zones adopt.ZoneIndex, rrsGetter adopt.RecordSetGetter, logger log.Logger) Watcher {
+	return &watcher{
+		cfg:       cfg,
+		zones:     zones,
+		rrsGetter: rrsGetter,
+		logger:    logger,
+	}
+}
+
+// snapshot are the record sets of the watched hosts of a zone by key.
+type snapshot map[string]route53.ResourceRecordSet
+
+func (w *watcher) Watch(ctx context.Context, results []*model.Result) (*Report, error) {
+	// Only the hosts that have been adopted, grouped by zone.
+	zoneHosts := map[string]map[string]bool{}
+	zoneNames := map[string]string{}
+	for _, res := range results {
+		if !res.Outcome.Success() || res.DryRun {
+			continue
+		}
+		id, zone := res.ZoneID, res.Zone
+		if id == "" {
+			hz, err := w.zones.Find(ctx, res.Host)
+			if err != nil {
+				return nil, err
+			}
+			id, zone = aws.StringValue(hz.Id), strings.TrimSuffix(aws.StringValue(hz.Name), ".")
+		}
+		if zoneHosts[id] == nil {
+			zoneHosts[id] = map[string]bool{}
+		}
+		zoneHosts[id][normalizeName(res.Host)] = true
+		zoneNames[id] = zone
+	}
+
+	report := &Report{Changes: []Change{}}
+	if len(zoneHosts) == 0 {
+		return report, nil
+	}
+
+	snapshots := map[string]snapshot{}
+	for id, hosts := range zoneHosts {
+		s, err := w.snapshot(ctx, id, hosts)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[id] = s
+	}
+	w.logger.Infof("watching %d record sets for %s", countRecordSets(snapshots), w.cfg.Duration)
+
+	// Poll at least once, even if the duration is shorter than the interval.
+	deadline := time.Now().Add(w.cfg.Duration)
+	for polls := 0; polls == 0 || time.Now().Add(w.cfg.Interval).Before(deadline); polls++ {
+		select {
+		case <-ctx.Done():
+			w.logger.Warningf("watch interrupted: %s", ctx.Err())
+			return report, nil
+		case <-time.After(w.cfg.Interval):
+		}
+
+		for id, hosts := range zoneHosts {
+			s, err := w.snapshot(ctx, id, hosts)
+			if err != nil {
+				return nil, err
+			}
+
+			changes := w.diff(snapshots[id], s)
+			for i := range changes {
+				changes[i].Zone = zoneNames[id]
+				w.logChange(changes[i])
+			}
+			report.Changes = append(report.Changes, changes...)
+			snapshots[id] = s
+		}
+
+		// The deletions stop the watch right away.
+		if report.Deletions() > 0 {
+			return report, nil
+		}
+	}
+
+	return report, nil
+}
+
+// snapshot returns the record sets of the hosts and their ownership TXT records, only the
+// record sets of these names are listed.
+func (w *watcher) snapshot(ctx context.Context, hzID string, hosts map[string]bool) (snapshot, error) {
+	rrs, err := w.rrsGetter.GetRecordSets(ctx, hzID, w.recordNames(hosts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := snapshot{}
+	for _, rs := range rrs {
+		if w.owner(rs, hosts) == "" {
+			continue
+		}
+		s[recordSetKey(rs)] = rs
+	}
+
+	return s, nil
+}
+
+// recordNames returns the names of the hosts and their ownership TXT records.
+func (w *watcher) recordNames(hosts map[string]bool) []string {
+	names := []string{}
+	for host := range hosts {
+		names = append(names, host)
+		for _, t := range []route53.RRType{route53.RRTypeA, route53.RRTypeAaaa, route53.RRTypeCname} {
+			names = append(names, w.cfg.Naming.TXTName(host, string(t)))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// owner returns the watched host of the record set, empty if it's not watched.
+func (w *watcher) owner(rs route53.ResourceRecordSet, hosts map[string]bool) string {
+	name := normalizeName(aws.StringValue(rs.Name))
+	if hosts[name] {
+		return name
+	}
+	if rs.Type == route53.RRTypeTxt {
+		if host, ok := w.cfg.Naming.Host(name); ok && hosts[host] {
+			return host
+		}
+	}
+	return ""
+}
+
+// diff returns the changes between two snapshots.
+func (w *watcher) diff(prev, cur snapshot) []Change {
+	now := time.Now()
+	changes := []Change{}
+	for k, o := range prev {
+		n, ok := cur[k]
+		if !ok {
+			changes = append(changes, Change{Time: now, RecordSet: k, Kind: KindDeleted, Host: w.host(o)})
+			continue
+		}
+		if details := describeChanges(o, n); len(details) > 0 {
+			changes = append(changes, Change{Time: now, RecordSet: k, Kind: KindChanged, Host: w.host(n), Details: details})
+		}
+	}
+	for k, n := range cur {
+		if _, ok := prev[k]; !ok {
+			changes = append(changes, Change{Time: now, RecordSet: k, Kind: KindCreated, Host: w.host(n), Details: []string{describeRecordSet(n)}})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].RecordSet < changes[j].RecordSet })
+
+	return changes
+}
+
+func (w *watcher) host(rs route53.ResourceRecordSet) string {
+	name := normalizeName(aws.StringValue(rs.Name))
+	if rs.Type == route53.RRTypeTxt {
+		if host, ok := w.cfg.Naming.Host(name); ok {
+			return host
+		}
+	}
+	return name
+}
+
+func (w *watcher) logChange(ch Change) {
+	logger := w.logger.With("hz", ch.Zone).With("host", ch.Host)
+	if ch.Kind == KindDeleted {
+		logger.Errorf("%s record set deleted", ch.RecordSet)
+		return
+	}
+	logger.Warningf("%s record set %s: %s", ch.RecordSet, ch.Kind, strings.Join(ch.Details, ", "))
+}
+
+// describeChanges returns the description of the changes of the targets, TTL, routing and
+// ownership values of a record set.
+func describeChanges(prev, cur route53.ResourceRecordSet) []string {
+	details := []string{}
+	add := func(field, p, c string) {
+		if p != c {
+			details = append(details, fmt.Sprintf("%s %s -> %s", field, p, c))
+		}
+	}
+
+	field := "targets"
+	if prev.Type == route53.RRTypeTxt {
+		field = "values"
+	}
+	add(field, targets(prev), targets(cur))
+	add("ttl", ttl(prev), ttl(cur))
+	add("routing", routing(prev), routing(cur))
+	add("health check", aws.StringValue(prev.HealthCheckId), aws.StringValue(cur.HealthCheckId))
+
+	return details
+}
+
+func describeRecordSet(rs route53.ResourceRecordSet) string {
+	return fmt.Sprintf("targets %s, ttl %s", targets(rs), ttl(rs))
+}
+
+func targets(rs route53.ResourceRecordSet) string {
+	if rs.AliasTarget != nil {
+		return fmt.Sprintf("[alias %s]", aws.StringValue(rs.AliasTarget.DNSName))
+	}
+	values := []string{}
+	for _, r := range rs.ResourceRecords {
+		values = append(values, aws.StringValue(r.Value))
+	}
+	return fmt.Sprintf("%v", values)
+}
+
+func ttl(rs route53.ResourceRecordSet) string {
+	if rs.TTL == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *rs.TTL)
+}
+
+func routing(rs route53.ResourceRecordSet) string {
+	switch {
+	case rs.Weight != nil:
+		return fmt.Sprintf("weight=%d", *rs.Weight)
+	case rs.Region != "":
+		return fmt.Sprintf("region=%s", rs.Region)
+	case rs.Failover != "":
+		return fmt.Sprintf("failover=%s", rs.Failover)
+	case rs.GeoLocation != nil:
+		g := rs.GeoLocation
+		return fmt.Sprintf("geo=%s/%s/%s", aws.StringValue(g.ContinentCode), aws.StringValue(g.CountryCode), aws.StringValue(g.SubdivisionCode))
+	}
+	return "simple"
+}
+
+func recordSetKey(rs route53.ResourceRecordSet) string {
+	k := fmt.Sprintf("%s %s", rs.Type, normalizeName(aws.StringValue(rs.Name)))
+	if id := aws.StringValue(rs.SetIdentifier); id != "" {
+		k = fmt.Sprintf("%s (%s)", k, id)
+	}
+	return k
+}
+
+func countRecordSets(snapshots map[string]snapshot) int {
+	n := 0
+	for _, s := range snapshots {
+		n += len(s)
+	}
+	return n
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,153 @@
+package watch_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/external-dns-aws-migrator/pkg/log"
+	"github.com/slok/external-dns-aws-migrator/pkg/mocks"
+	mroute53iface "github.com/slok/external-dns-aws-migrator/pkg/mocks/github.com/aws/aws-sdk-go-v2/service/route53/route53iface"
+	"github.com/slok/external-dns-aws-migrator/pkg/model"
+	"github.com/slok/external-dns-aws-migrator/pkg/registry"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/adopt"
+	"github.com/slok/external-dns-aws-migrator/pkg/service/watch"
+)
+
+func TestWatcherWatch(t *testing.T) {
+	initial := []route53.ResourceRecordSet{
+		mocks.RecordSet("batman.gotham.dc.comics.", route53.RRTypeA, 60, "10.0.0.1"),
+		mocks.TXTRecordSet("registry-batman.gotham.dc.comics.", `"heritage=external-dns,external-dns/owner=batman"`),
+		// Not adopted.
+		mocks.RecordSet("joker.gotham.dc.comics.", route53.RRTypeA, 60, "10.0.0.2"),
+	}
+
+	tests := []struct {
+		name       string
+		duration   time.Duration
+		results    []*model.Result
+		changed    []route53.ResourceRecordSet
+		expChanges map[string]watch.Kind
+		expDetails []string
+	}{
+		{
+			name:       "Watching without changes should not report anything.",
+			results:    []*model.Result{{Host: "batman.gotham.dc.comics", Zone: "gotham.dc.comics", ZoneID: "gotham", Outcome: model.OutcomeAdopted}},
+			changed:    initial,
+			expChanges: map[string]watch.Kind{},
+		},
+		{
+			name:    "Watching should report the changed and deleted record sets of the adopted hosts.",
+			results: []*model.Result{{Host: "batman.gotham.dc.comics", Zone: "gotham.dc.comics", ZoneID: "gotham", Outcome: model.OutcomeAdopted}},
+			changed: []route53.ResourceRecordSet{
+				mocks.RecordSet("batman.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.1"),
+				mocks.RecordSet("joker.gotham.dc.comics.", route53.RRTypeA, 60, "10.0.0.100"),
+			},
+			expChanges: map[string]watch.Kind{
+				"A batman.gotham.dc.comics":            watch.KindChanged,
+				"TXT registry-batman.gotham.dc.comics": watch.KindDeleted,
+			},
+			expDetails: []string{"ttl 60 -> 300"},
+		},
+		{
+			name:     "Watching should stop as soon as a record set is deleted.",
+			duration: time.Hour,
+			results:  []*model.Result{{Host: "batman.gotham.dc.comics", Zone: "gotham.dc.comics", ZoneID: "gotham", Outcome: model.OutcomeAdopted}},
+			changed:  initial[:1],
+			expChanges: map[string]watch.Kind{
+				"TXT registry-batman.gotham.dc.comics": watch.KindDeleted,
+			},
+		},
+		{
+			name:     "Watching less time than the interval should poll once.",
+			duration: time.Millisecond,
+			results:  []*model.Result{{Host: "batman.gotham.dc.comics", Zone: "gotham.dc.comics", ZoneID: "gotham", Outcome: model.OutcomeAdopted}},
+			changed: []route53.ResourceRecordSet{
+				mocks.RecordSet("batman.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.1"),
+				initial[1],
+			},
+			expChanges: map[string]watch.Kind{
+				"A batman.gotham.dc.comics": watch.KindChanged,
+			},
+		},
+		{
+			name:    "Watching should not watch the hosts that were not adopted.",
+			results: []*model.Result{{Host: "batman.gotham.dc.comics", Zone: "gotham.dc.comics", ZoneID: "gotham", Outcome: model.OutcomeOwnerConflict}},
+			changed: []route53.ResourceRecordSet{
+				mocks.RecordSet("joker.gotham.dc.comics.", route53.RRTypeA, 60, "10.0.0.100"),
+			},
+			expChanges: map[string]watch.Kind{},
+		},
+		{
+			name:    "Watching the results without hosted zone should find the hosted zone of the hosts.",
+			results: []*model.Result{{Host: "batman.gotham.dc.comics", Outcome: model.OutcomeAdopted}},
+			changed: []route53.ResourceRecordSet{
+				mocks.RecordSet("batman.gotham.dc.comics.", route53.RRTypeA, 300, "10.0.0.1"),
+				initial[1],
+			},
+			expChanges: map[string]watch.Kind{
+				"A batman.gotham.dc.comics": watch.KindChanged,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mr53 := &mroute53iface.Route53API{}
+			mr53.On("ListHostedZonesRequest", mock.Anything).Return(mocks.ListHostedZonesRequest(
+				route53.HostedZone{Name: aws.String("gotham.dc.comics."), Id: aws.String("gotham")},
+			))
+			// Only the names of the host and its ownership TXT are listed, each snapshot
+			// lists both of them.
+			watched := func(input *route53.ListResourceRecordSetsInput) bool {
+				name := aws.StringValue(input.StartRecordName)
+				return aws.StringValue(input.HostedZoneId) == "gotham" &&
+					(name == "batman.gotham.dc.comics." || name == "registry-batman.gotham.dc.comics.")
+			}
+			mr53.On("ListResourceRecordSetsRequest", mock.MatchedBy(watched)).Times(2).Return(mocks.ListResourceRecordSetsRequest(initial...))
+			mr53.On("ListResourceRecordSetsRequest", mock.MatchedBy(watched)).Return(mocks.ListResourceRecordSetsRequest(test.changed...))
+
+			duration := test.duration
+			if duration == 0 {
+				duration = 50 * time.Millisecond
+			}
+			w := watch.NewWatcher(watch.Config{
+				Duration: duration,
+				Interval: 10 * time.Millisecond,
+				Naming:   registry.Naming{Prefix: "registry-"},
+			}, adopt.NewZoneIndex(mr53), adopt.NewRecordSetGetter(mr53), log.Dummy)
+
+			// The watch should never last until the context timeout.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			report, err := w.Watch(ctx, test.results)
+			require.NoError(err)
+			assert.NoError(ctx.Err())
+
+			// Each change is reported once.
+			gotChanges := map[string]watch.Kind{}
+			gotDetails := []string{}
+			for _, ch := range report.Changes {
+				_, dup := gotChanges[ch.RecordSet]
+				assert.False(dup)
+				gotChanges[ch.RecordSet] = ch.Kind
+				assert.Equal("batman.gotham.dc.comics", ch.Host)
+				gotDetails = append(gotDetails, ch.Details...)
+			}
+			assert.Equal(test.expChanges, gotChanges)
+			if len(test.expDetails) > 0 {
+				assert.Equal(test.expDetails, gotDetails)
+			}
+		})
+	}
+}